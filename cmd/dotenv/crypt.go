@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/stackus/dotenv"
+)
+
+// variableReference matches the start of a `$NAME`, `${...}` or `$(...)` expansion
+var variableReference = regexp.MustCompile(`\$[{(A-Za-z_]`)
+
+// runEncrypt encrypts values in a file, creating a key pair when the file does not have a public key yet
+func runEncrypt(args []string) error {
+	flags := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	file := flags.String("f", ".env", "the file to edit")
+	keyFile := flags.String("k", ".env.keys", "the file the private key is written to when a new key pair is created")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s encrypt [-f file] [-k key file] [KEY...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	return editFile(*file, 0o644, func(doc *dotenv.Document) error {
+		publicKey, exists := doc.Get(dotenv.PublicKeyName)
+		// privateKey is only set when a new key pair is created
+		privateKey := ""
+		if !exists {
+			var err error
+			if publicKey, privateKey, err = keyPair(*keyFile); err != nil {
+				return err
+			}
+			if err = doc.Set(dotenv.PublicKeyName, publicKey); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range documentKeys(doc, flags.Args()) {
+			value, exists := doc.Get(key)
+			if !exists {
+				return fmt.Errorf("key is not set: %s", key)
+			}
+			if key != dotenv.PublicKeyName && doc.Count(key) > 1 {
+				// only the last definition would be encrypted
+				return fmt.Errorf("%s is defined more than once; remove the earlier definitions before encrypting it", key)
+			}
+			if key == dotenv.PublicKeyName || dotenv.IsEncrypted(value) {
+				continue
+			}
+			if variableReference.MatchString(value) {
+				// decrypted values are never expanded
+				log.Printf("skipping %s: the value refers to other variables", key)
+				continue
+			}
+
+			encrypted, err := dotenv.EncryptValue(publicKey, value)
+			if err != nil {
+				return err
+			}
+			if err = doc.Set(key, encrypted); err != nil {
+				return err
+			}
+		}
+
+		// the private key is only written once every key has been encrypted; when the file then cannot be
+		// written, the next run finds the private key and adds its public key again
+		switch {
+		case privateKey != "":
+			if err := editFile(*keyFile, 0o600, func(keys *dotenv.Document) error {
+				return keys.Set(dotenv.PrivateKeyName, privateKey)
+			}); err != nil {
+				return err
+			}
+			log.Printf("created a new key pair; keep %s out of version control", *keyFile)
+		case !exists:
+			log.Printf("added the public key for the private key in %s", *keyFile)
+		}
+
+		return nil
+	})
+}
+
+// runDecrypt replaces the encrypted values in a file with their decrypted values
+func runDecrypt(args []string) error {
+	flags := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	file := flags.String("f", ".env", "the file to edit")
+	keyFile := flags.String("k", ".env.keys", "the file with the private key; the DOTENV_PRIVATE_KEY environment variable is used when it does not exist")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s decrypt [-f file] [-k key file] [KEY...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	privateKey, err := readPrivateKey(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	return editFile(*file, 0o644, func(doc *dotenv.Document) error {
+		for _, key := range documentKeys(doc, flags.Args()) {
+			value, exists := doc.Get(key)
+			if !exists {
+				return fmt.Errorf("key is not set: %s", key)
+			}
+			if !dotenv.IsEncrypted(value) {
+				continue
+			}
+
+			decrypted, err := dotenv.DecryptValue(privateKey, value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err = doc.Set(key, decrypted); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// documentKeys returns keys, or every key in the document when no keys were given
+func documentKeys(doc *dotenv.Document, keys []string) []string {
+	if len(keys) > 0 {
+		return keys
+	}
+
+	return doc.Keys()
+}
+
+// keyPair returns the public key for the private key in keyFile, or a new key pair when keyFile does not have one
+//
+// The private key is only returned for a new key pair, which has not been written to keyFile yet. An existing private
+// key is never replaced, since values encrypted for it could no longer be decrypted.
+func keyPair(keyFile string) (publicKey, privateKey string, err error) {
+	contents, err := os.ReadFile(keyFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", "", err
+	}
+	keys, err := dotenv.ParseDocument(bytes.NewReader(contents))
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", keyFile, err)
+	}
+	if existing, exists := keys.Get(dotenv.PrivateKeyName); exists {
+		if publicKey, err = dotenv.PublicKey(existing); err != nil {
+			return "", "", fmt.Errorf("%s: %w", keyFile, err)
+		}
+		return publicKey, "", nil
+	}
+
+	return dotenv.GenerateKey()
+}
+
+// readPrivateKey reads the private key from keyFile, or from the environment when keyFile does not exist
+func readPrivateKey(keyFile string) (string, error) {
+	f, err := os.Open(keyFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if privateKey, exists := os.LookupEnv(dotenv.PrivateKeyName); exists {
+			return privateKey, nil
+		}
+		return "", fmt.Errorf("%s does not exist and %s is not set", keyFile, dotenv.PrivateKeyName)
+	case err != nil:
+		return "", err
+	}
+	defer f.Close()
+
+	doc, err := dotenv.ParseDocument(f)
+	if err != nil {
+		return "", err
+	}
+	privateKey, exists := doc.Get(dotenv.PrivateKeyName)
+	if !exists {
+		return "", fmt.Errorf("%s is not set in %s", dotenv.PrivateKeyName, keyFile)
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stackus/dotenv"
+)
+
+func TestRunEncrypt(t *testing.T) {
+	_, existingKey, err := dotenv.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		contents string
+		// keys is written to the key file first unless it is empty
+		keys    string
+		args    []string
+		want    map[string]string
+		wantErr bool
+	}{
+		"creates a key pair": {
+			contents: "A=1\nB=two words\n",
+			args:     []string{},
+			want:     map[string]string{"A": "1", "B": "two words"},
+			wantErr:  false,
+		},
+		"adds the public key for an existing private key": {
+			contents: "A=1\n",
+			keys:     dotenv.PrivateKeyName + "=" + existingKey + "\n",
+			args:     []string{},
+			want:     map[string]string{"A": "1"},
+			wantErr:  false,
+		},
+		"writes nothing when a key is not set": {
+			contents: "A=1\n",
+			args:     []string{"A", "TYPO"},
+			want:     nil,
+			wantErr:  true,
+		},
+		"rejects keys that are defined more than once": {
+			contents: "SECRET=old\nSECRET=new\n",
+			args:     []string{},
+			want:     nil,
+			wantErr:  true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			file, keyFile := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.keys")
+			if err := os.WriteFile(file, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.keys != "" {
+				if err := os.WriteFile(keyFile, []byte(tt.keys), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := runEncrypt(append([]string{"-f", file, "-k", keyFile}, tt.args...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runEncrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if got, err := os.ReadFile(file); err != nil || string(got) != tt.contents {
+					t.Errorf("runEncrypt() changed the file: %q, %v", got, err)
+				}
+				if _, err := os.Stat(keyFile); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("runEncrypt() wrote the key file: %v", err)
+				}
+				return
+			}
+
+			privateKey, err := readPrivateKey(keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := dotenv.ParseDocument(bytes.NewReader(contents))
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, _ := doc.Get(dotenv.PublicKeyName)
+			if want, _ := dotenv.PublicKey(privateKey); publicKey != want {
+				t.Errorf("runEncrypt() public key = %q, want %q", publicKey, want)
+			}
+
+			got := make(map[string]string)
+			for _, key := range doc.Keys() {
+				if key == dotenv.PublicKeyName {
+					continue
+				}
+				value, _ := doc.Get(key)
+				if got[key], err = dotenv.DecryptValue(privateKey, value); err != nil {
+					t.Fatalf("DecryptValue() error = %v", err)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runEncrypt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/stackus/dotenv"
+)
+
+// runGet prints the value of a key after every file has been parsed
+func runGet(args []string) error {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	var pf parseFlags
+	pf.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s get [-e environment] [-f file] [-p path] KEY\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a single key")
+	}
+
+	vars, err := dotenv.Parse(pf.options()...)
+	if err != nil {
+		return err
+	}
+
+	key := flags.Arg(0)
+	value, exists := vars[key]
+	if !exists {
+		return fmt.Errorf("key is not set: %s", key)
+	}
+	fmt.Println(value)
+
+	return nil
+}
+
+// runSet sets one or more keys in a file, creating the file when it does not exist
+func runSet(args []string) error {
+	flags := flag.NewFlagSet("set", flag.ExitOnError)
+	file := flags.String("f", ".env", "the file to edit")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s set [-f file] KEY=value [KEY=value...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected at least one KEY=value pair")
+	}
+
+	return editFile(*file, 0o644, func(doc *dotenv.Document) error {
+		for _, arg := range flags.Args() {
+			pair := strings.SplitN(arg, "=", 2)
+			if len(pair) != 2 {
+				return fmt.Errorf("expected KEY=value, got %q", arg)
+			}
+			if err := doc.Set(pair[0], pair[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runUnset removes one or more keys from a file
+func runUnset(args []string) error {
+	flags := flag.NewFlagSet("unset", flag.ExitOnError)
+	file := flags.String("f", ".env", "the file to edit")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s unset [-f file] KEY [KEY...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected at least one key")
+	}
+
+	return editFile(*file, 0o644, func(doc *dotenv.Document) error {
+		for _, key := range flags.Args() {
+			doc.Delete(key)
+		}
+		return nil
+	})
+}
+
+// editFile applies edit to the document read from fileName and writes it back, keeping the file mode
+//
+// mode is used when the file does not exist yet.
+func editFile(fileName string, mode fs.FileMode, edit func(doc *dotenv.Document) error) error {
+	contents, err := os.ReadFile(fileName)
+	switch {
+	case err == nil:
+		if info, err := os.Stat(fileName); err == nil {
+			mode = info.Mode().Perm()
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	doc, err := dotenv.ParseDocument(bytes.NewReader(contents))
+	if err != nil {
+		return err
+	}
+
+	if err = edit(doc); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if _, err = doc.WriteTo(&b); err != nil {
+		return err
+	}
+
+	return os.WriteFile(fileName, b.Bytes(), mode)
+}
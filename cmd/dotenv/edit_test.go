@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stackus/dotenv"
+)
+
+func TestEditFile(t *testing.T) {
+	tests := map[string]struct {
+		// contents is written to the file first unless it is empty
+		contents string
+		fileMode fs.FileMode
+		edit     func(doc *dotenv.Document) error
+		want     string
+		wantMode fs.FileMode
+		wantErr  bool
+	}{
+		"keeps the file mode": {
+			contents: "A=\"x\"\n",
+			fileMode: 0o600,
+			edit:     func(doc *dotenv.Document) error { return doc.Set("A", "y") },
+			want:     "A=\"y\"\n",
+			wantMode: 0o600,
+			wantErr:  false,
+		},
+		"creates missing files": {
+			edit:     func(doc *dotenv.Document) error { return doc.Set("A", "1") },
+			want:     "A=1\n",
+			wantMode: 0o600,
+			wantErr:  false,
+		},
+		"sets empty values": {
+			contents: "A=\"x\"\n",
+			fileMode: 0o600,
+			edit:     func(doc *dotenv.Document) error { return doc.Set("A", "") },
+			want:     "A=\"\"\n",
+			wantMode: 0o600,
+			wantErr:  false,
+		},
+		"leaves the file alone when the edit fails": {
+			contents: "A=1\n",
+			fileMode: 0o600,
+			edit:     func(doc *dotenv.Document) error { return errors.New("failed") },
+			want:     "A=1\n",
+			wantMode: 0o600,
+			wantErr:  true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), ".env")
+			if tt.contents != "" {
+				if err := os.WriteFile(file, []byte(tt.contents), tt.fileMode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 0o600 is used for new files so that the umask does not change the mode
+			err := editFile(file, 0o600, tt.edit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("editFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("editFile() got = %q, want %q", got, tt.want)
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); runtime.GOOS != "windows" && mode != tt.wantMode {
+				t.Errorf("editFile() mode = %v, want %v", mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestRunSetAndUnset(t *testing.T) {
+	tests := map[string]struct {
+		contents string
+		run      func(args []string) error
+		args     []string
+		want     string
+		wantErr  bool
+	}{
+		"sets values": {
+			contents: "A=1\nB='old'\n",
+			run:      runSet,
+			args:     []string{"B=new", "C=two words"},
+			want:     "A=1\nB='new'\nC=\"two words\"\n",
+			wantErr:  false,
+		},
+		"sets empty values": {
+			contents: "A=\"x\"\n",
+			run:      runSet,
+			args:     []string{"A="},
+			want:     "A=\"\"\n",
+			wantErr:  false,
+		},
+		"rejects keys without a value": {
+			contents: "A=1\n",
+			run:      runSet,
+			args:     []string{"B=2", "KEY"},
+			want:     "A=1\n",
+			wantErr:  true,
+		},
+		"rejects invalid keys": {
+			contents: "A=1\n",
+			run:      runSet,
+			args:     []string{"NOT VALID=1"},
+			want:     "A=1\n",
+			wantErr:  true,
+		},
+		"unsets values": {
+			contents: "A=1\nexport B=2\nC=3\nexport B\n",
+			run:      runUnset,
+			args:     []string{"B", "MISSING"},
+			want:     "A=1\nC=3\n",
+			wantErr:  false,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(file, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.run(append([]string{"-f", file}, tt.args...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunGet(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("DOTENV_TEST_GET=\"two words\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		"prints the value": {
+			args:    []string{"-p", dir, "DOTENV_TEST_GET"},
+			want:    "two words\n",
+			wantErr: false,
+		},
+		"rejects keys that are not set": {
+			args:    []string{"-p", dir, "DOTENV_TEST_MISSING"},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdout := os.Stdout
+			os.Stdout = w
+			err = runGet(tt.args)
+			os.Stdout = stdout
+			_ = w.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runGet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("runGet() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// execCommand replaces the current process with the command
+func execCommand(args, environ []string) error {
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	return syscall.Exec(path, args, environ)
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"errors"
+)
+
+// execCommand is not supported on Windows, which is unable to replace the current process
+func execCommand(args, environ []string) error {
+	return errors.New("exec mode is not supported on windows")
+}
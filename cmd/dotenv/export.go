@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/stackus/dotenv"
+)
+
+// exportFormat writes vars to w; keys holds the keys of vars in sorted order
+type exportFormat func(w io.Writer, keys []string, vars map[string]string) error
+
+var exportFormats = map[string]exportFormat{
+	"bash":       exportBash,
+	"fish":       exportFish,
+	"powershell": exportPowerShell,
+	"json":       exportJSON,
+	"yaml":       exportYAML,
+	"docker":     exportDocker,
+	"systemd":    exportSystemd,
+}
+
+// runExport prints the parsed values in a format other tools can read
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	var pf parseFlags
+	pf.register(flags)
+	format := flags.String("format", "bash", "the output format: bash, fish, powershell, json, yaml, docker or systemd")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s export [-format format] [-e environment] [-f file] [-p path]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	export, exists := exportFormats[*format]
+	if !exists {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+
+	vars, err := dotenv.Parse(pf.options()...)
+	if err != nil {
+		return err
+	}
+
+	// write everything or nothing so that a failure never leaves partial output to be evaluated
+	var b bytes.Buffer
+	if err = export(&b, sortedKeys(vars), vars); err != nil {
+		return err
+	}
+
+	_, err = b.WriteTo(os.Stdout)
+
+	return err
+}
+
+// isVariableName reports whether key can be used as a variable name by a shell, which rules out the dots
+// and leading digits that keys may have
+func isVariableName(key string) bool {
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return key != ""
+}
+
+func sortedKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// exportBash writes single quoted values, which the shell never expands and may span lines
+func exportBash(w io.Writer, keys []string, vars map[string]string) error {
+	for _, key := range keys {
+		if !isVariableName(key) {
+			return fmt.Errorf("the bash format does not support keys that are not valid variable names: %s", key)
+		}
+		value := strings.ReplaceAll(vars[key], `'`, `'\''`)
+		if _, err := fmt.Fprintf(w, "export %s='%s'\n", key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportFish(w io.Writer, keys []string, vars map[string]string) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, key := range keys {
+		if !isVariableName(key) {
+			return fmt.Errorf("the fish format does not support keys that are not valid variable names: %s", key)
+		}
+		if _, err := fmt.Fprintf(w, "set -gx %s '%s'\n", key, replacer.Replace(vars[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportPowerShell braces the names of keys that PowerShell would otherwise read as a property access, such as `A.B`
+func exportPowerShell(w io.Writer, keys []string, vars map[string]string) error {
+	for _, key := range keys {
+		name := "$env:" + key
+		if !isVariableName(key) {
+			name = "${env:" + key + "}"
+		}
+		value := strings.ReplaceAll(vars[key], `'`, `''`)
+		if _, err := fmt.Fprintf(w, "%s = '%s'\n", name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportJSON(w io.Writer, _ []string, vars map[string]string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(vars)
+}
+
+// exportYAML writes keys and values as JSON strings, which are also valid YAML double quoted scalars
+func exportYAML(w io.Writer, keys []string, vars map[string]string) error {
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", jsonString(key), jsonString(vars[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportDocker writes the format read by `docker run --env-file`, which takes each value literally
+func exportDocker(w io.Writer, keys []string, vars map[string]string) error {
+	for _, key := range keys {
+		if strings.ContainsAny(vars[key], "\r\n") {
+			return fmt.Errorf("the docker format does not support multi-line values: %s", key)
+		}
+	}
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, vars[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportSystemd writes the format read by the EnvironmentFile= directive
+func exportSystemd(w io.Writer, keys []string, vars map[string]string) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=\"%s\"\n", key, replacer.Replace(vars[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func jsonString(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	// encoding a string never fails
+	_ = encoder.Encode(s)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportFormats(t *testing.T) {
+	vars := map[string]string{
+		"QUOTES": `it's "quoted" \ $HOME`,
+		"SIMPLE": "value",
+	}
+	multiLine := map[string]string{
+		"LINES": "two\nlines",
+	}
+	dotted := map[string]string{
+		"APP.NAME": "api",
+	}
+
+	tests := map[string]struct {
+		format  string
+		vars    map[string]string
+		want    string
+		wantErr bool
+	}{
+		"bash": {
+			format: "bash",
+			vars:   vars,
+			want:   "export QUOTES='it'\\''s \"quoted\" \\ $HOME'\nexport SIMPLE='value'\n",
+		},
+		"bash multi-line": {
+			format: "bash",
+			vars:   multiLine,
+			want:   "export LINES='two\nlines'\n",
+		},
+		"bash dotted keys": {
+			format:  "bash",
+			vars:    dotted,
+			wantErr: true,
+		},
+		"bash leading digits": {
+			format:  "bash",
+			vars:    map[string]string{"1KEY": "value"},
+			wantErr: true,
+		},
+		"fish": {
+			format: "fish",
+			vars:   vars,
+			want:   "set -gx QUOTES 'it\\'s \"quoted\" \\\\ $HOME'\nset -gx SIMPLE 'value'\n",
+		},
+		"fish dotted keys": {
+			format:  "fish",
+			vars:    dotted,
+			wantErr: true,
+		},
+		"powershell": {
+			format: "powershell",
+			vars:   vars,
+			want:   "$env:QUOTES = 'it''s \"quoted\" \\ $HOME'\n$env:SIMPLE = 'value'\n",
+		},
+		"powershell dotted keys": {
+			format: "powershell",
+			vars:   dotted,
+			want:   "${env:APP.NAME} = 'api'\n",
+		},
+		"json": {
+			format: "json",
+			vars:   vars,
+			want:   "{\n  \"QUOTES\": \"it's \\\"quoted\\\" \\\\ $HOME\",\n  \"SIMPLE\": \"value\"\n}\n",
+		},
+		"yaml": {
+			format: "yaml",
+			vars:   multiLine,
+			want:   "\"LINES\": \"two\\nlines\"\n",
+		},
+		"docker": {
+			format: "docker",
+			vars:   vars,
+			want:   "QUOTES=it's \"quoted\" \\ $HOME\nSIMPLE=value\n",
+		},
+		"docker multi-line": {
+			format:  "docker",
+			vars:    multiLine,
+			wantErr: true,
+		},
+		"systemd": {
+			format: "systemd",
+			vars:   vars,
+			want:   "QUOTES=\"it's \\\"quoted\\\" \\\\ $HOME\"\nSIMPLE=\"value\"\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b strings.Builder
+			err := exportFormats[tt.format](&b, sortedKeys(tt.vars), tt.vars)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("export error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := b.String(); !tt.wantErr && got != tt.want {
+				t.Errorf("export got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stackus/dotenv"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// runFmt formats files, printing the result, a diff, or writing the files in place
+func runFmt(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the file instead of printing it")
+	diff := flags.Bool("d", false, "print a diff of the changes instead of the formatted file")
+	sortKeys := flags.Bool("s", false, "sort the keys within each group of lines separated by blank lines")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s fmt [-w] [-d] [-s] [file...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	fileNames := flags.Args()
+	if len(fileNames) == 0 {
+		fileNames = []string{".env"}
+	}
+
+	for _, fileName := range fileNames {
+		contents, err := os.ReadFile(fileName)
+		if err != nil {
+			return err
+		}
+		formatted, err := dotenv.Format(contents, *sortKeys)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fileName, err)
+		}
+
+		changed := !bytes.Equal(contents, formatted)
+		if *diff && changed {
+			if err = writeDiff(os.Stdout, fileName, string(contents), string(formatted)); err != nil {
+				return err
+			}
+		}
+		if *write && changed {
+			info, err := os.Stat(fileName)
+			if err != nil {
+				return err
+			}
+			if err = os.WriteFile(fileName, formatted, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		if !*write && !*diff {
+			if _, err = os.Stdout.Write(formatted); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffLine is a line of a diff; op is ' ' for an unchanged line, '-' for a removed line and '+' for an added line
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// writeDiff writes the changes from before to after as a unified diff
+func writeDiff(w io.Writer, fileName, before, after string) error {
+	lines := lineDiff(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", fileName, fileName)
+	// removed and added count the lines of before and after that come before line i
+	removed, added := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			removed, added = removed+1, added+1
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				if end += diffContext; end > next {
+					end = next
+				}
+				break
+			}
+			end = next
+		}
+
+		beforeStart, beforeCount := removed-(i-start), 0
+		afterStart, afterCount := added-(i-start), 0
+		for _, line := range lines[start:end] {
+			if line.op != '+' {
+				beforeCount++
+			}
+			if line.op != '-' {
+				afterCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(beforeStart, beforeCount), hunkRange(afterStart, afterCount))
+		for _, line := range lines[start:end] {
+			b.WriteByte(line.op)
+			b.WriteString(strings.TrimRight(line.text, "\r\n"))
+			b.WriteByte('\n')
+		}
+
+		for _, line := range lines[i:end] {
+			if line.op != '+' {
+				removed++
+			}
+			if line.op != '-' {
+				added++
+			}
+		}
+		i = end
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// hunkRange returns the range of a hunk, where before is the number of lines that come before it
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// lineDiff returns the shortest list of changes that turns a into b
+func lineDiff(a, b []string) []diffLine {
+	return appendDiff(make([]diffLine, 0, len(a)+len(b)), a, b)
+}
+
+// appendDiff appends the changes that turn a into b to lines
+//
+// This is the linear space variant of Myers' diff algorithm: the lines that a and b start and end with are
+// kept, and what is left is split at a point on the shortest path between them until nothing is left in
+// common. The memory used only grows with the number of lines rather than with their product.
+func appendDiff(lines []diffLine, a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines = appendLines(lines, ' ', a[:prefix])
+	changedA, changedB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(changedA) == 0:
+		lines = appendLines(lines, '+', changedB)
+	case len(changedB) == 0:
+		lines = appendLines(lines, '-', changedA)
+	default:
+		x, y := middleSnake(changedA, changedB)
+		lines = appendDiff(lines, changedA[:x], changedB[:y])
+		lines = appendDiff(lines, changedA[x:], changedB[y:])
+	}
+
+	return appendLines(lines, ' ', a[len(a)-suffix:])
+}
+
+// middleSnake returns a point that a shortest path from the start of a and b to their ends goes through
+//
+// The paths are followed from the start and, in reverse, from the end at the same time until they meet.
+// Both a and b must be non-empty and must differ in their first and last lines. When they have nothing
+// in common the end of a and the start of b is returned, so every line of a is removed before b is added.
+func middleSnake(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward holds the furthest x reached on each diagonal k = x - y, at index offset+k, following the
+	// paths from the start; backward holds the same for the paths that start at the end of a and b
+	forward, backward := make([]int, 2*offset+1), make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// the forward paths can only meet the backward paths when delta is odd, and the other way around
+	odd := delta%2 != 0
+	// diagonals that have run past the end of a or b are skipped from then on
+	forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			i := offset + k
+			x := forward[i-1] + 1
+			if k == -d || k != d && forward[i-1] < forward[i+1] {
+				x = forward[i+1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			forward[i] = x
+
+			switch j := offset + delta - k; {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case odd && j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j]:
+				return x, y
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			i := offset + k
+			x := backward[i-1] + 1
+			if k == -d || k != d && backward[i-1] < backward[i+1] {
+				x = backward[i+1]
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x, y = x+1, y+1
+			}
+			backward[i] = x
+
+			switch j := offset + delta - k; {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !odd && j >= 0 && j < len(forward) && forward[j] != -1 && forward[j] >= n-x:
+				return forward[j], forward[j] - (j - offset)
+			}
+		}
+	}
+
+	return n, 0
+}
+
+// appendLines appends each of texts to lines with op
+func appendLines(lines []diffLine, op byte, texts []string) []diffLine {
+	for _, text := range texts {
+		lines = append(lines, diffLine{op: op, text: text})
+	}
+
+	return lines
+}
+
+// splitLines splits s into lines that keep their line endings
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteDiff(t *testing.T) {
+	tests := map[string]struct {
+		before string
+		after  string
+		want   string
+	}{
+		"changed lines": {
+			before: "A = 1\nB=2\n",
+			after:  "A=1\nB=2\n",
+			want:   "--- a/.env\n+++ b/.env\n@@ -1,2 +1,2 @@\n-A = 1\n+A=1\n B=2\n",
+		},
+		"separate hunks": {
+			before: "A = 1\n2\n3\n4\n5\n6\n7\n8\nI = 9\n",
+			after:  "A=1\n2\n3\n4\n5\n6\n7\n8\nI=9\n",
+			want: "--- a/.env\n+++ b/.env\n@@ -1,4 +1,4 @@\n-A = 1\n+A=1\n 2\n 3\n 4\n" +
+				"@@ -6,4 +6,4 @@\n 6\n 7\n 8\n-I = 9\n+I=9\n",
+		},
+		"changes between unchanged lines": {
+			before: "A = 1\nB=2\nC = 3\nD=4\n",
+			after:  "A=1\nB=2\nC=3\nD=4\n",
+			want:   "--- a/.env\n+++ b/.env\n@@ -1,4 +1,4 @@\n-A = 1\n+A=1\n B=2\n-C = 3\n+C=3\n D=4\n",
+		},
+		"removed lines": {
+			before: "A=1\n\n\nB=2\n",
+			after:  "A=1\n\nB=2\n",
+			want:   "--- a/.env\n+++ b/.env\n@@ -1,4 +1,3 @@\n A=1\n \n-\n B=2\n",
+		},
+		"missing line ending": {
+			before: "A=1",
+			after:  "A=1\n",
+			want:   "--- a/.env\n+++ b/.env\n@@ -1,1 +1,1 @@\n-A=1\n+A=1\n",
+		},
+		"empty file": {
+			before: "",
+			after:  "A=1\n",
+			want:   "--- a/.env\n+++ b/.env\n@@ -0,0 +1,1 @@\n+A=1\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeDiff(&b, ".env", tt.before, tt.after); err != nil {
+				t.Fatalf("writeDiff() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeDiff() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
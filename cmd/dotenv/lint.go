@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stackus/dotenv"
+)
+
+// runLint reports problems in the files and fails when any are found so that it can be used in CI
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	var pf parseFlags
+	pf.register(flags)
+	format := flags.String("format", "text", "the output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s lint [-format format] [-e environment] [-f file] [-p path]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+
+	issues, err := dotenv.Lint(pf.options()...)
+	if err != nil {
+		return err
+	}
+	if wd, err := os.Getwd(); err == nil {
+		relativeFiles(issues, wd)
+	}
+
+	if *format == "json" {
+		err = writeIssuesJSON(os.Stdout, issues)
+	} else {
+		err = writeIssues(os.Stdout, issues)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d issue(s)", len(issues))
+	}
+
+	return nil
+}
+
+// relativeFiles shortens the paths of files within dir
+func relativeFiles(issues []dotenv.Issue, dir string) {
+	for i, issue := range issues {
+		if rel, err := filepath.Rel(dir, issue.File); err == nil && !strings.HasPrefix(rel, "..") {
+			issues[i].File = rel
+		}
+	}
+}
+
+func writeIssues(w io.Writer, issues []dotenv.Issue) error {
+	for _, issue := range issues {
+		if _, err := fmt.Fprintln(w, issue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeIssuesJSON(w io.Writer, issues []dotenv.Issue) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(issues)
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"os/signal"
 	"strings"
 
 	"github.com/stackus/dotenv"
@@ -13,81 +13,180 @@ import (
 
 type flagStrSlice []string
 
-var files flagStrSlice
-var paths flagStrSlice
-var environment string
+// parseFlags are the flags used to choose which files are parsed and how
+type parseFlags struct {
+	files        flagStrSlice
+	paths        flagStrSlice
+	environment  string
+	overload     bool
+	requiredKeys flagStrSlice
+	requireFiles bool
+	keyFile      string
+}
+
+// subcommands are run when their name is the first argument
+var subcommands = map[string]func(args []string) error{
+	"get":     runGet,
+	"set":     runSet,
+	"unset":   runUnset,
+	"export":  runExport,
+	"watch":   runWatch,
+	"encrypt": runEncrypt,
+	"decrypt": runDecrypt,
+	"lint":    runLint,
+	"fmt":     runFmt,
+}
 
 func main() {
-	flag.Var(&files, "f", "[optional] [repeatable] files with key:value pairs to set into the current environment")
-	flag.StringVar(&environment, "e", "", "[optional] sets the environment to load a suite of files")
-	flag.Var(&paths, "p", "[optional] [repeatable] one or more paths to search for files")
+	if len(os.Args) > 1 {
+		if subcommand, exists := subcommands[os.Args[1]]; exists {
+			if err := subcommand(os.Args[2:]); err != nil {
+				log.Fatal(os.Args[1], ": ", err)
+			}
+			return
+		}
+	}
+
+	var pf parseFlags
+	pf.register(flag.CommandLine)
+	execMode := flag.Bool("exec", false, "[optional] replace the dotenv process with the command instead of running it as a child process")
+	var ignoreEnvironment bool
+	var allowedKeys flagStrSlice
+	flag.BoolVar(&ignoreEnvironment, "i", false, "[optional] start the command with only the parsed variables and any allowed keys, like env -i")
+	flag.BoolVar(&ignoreEnvironment, "ignore-environment", false, "[optional] same as -i")
+	flag.Var(&allowedKeys, "a", "[optional] [repeatable] a key to pass on from the current environment when -i is used")
+	flag.Var(&allowedKeys, "allow", "[optional] [repeatable] same as -a")
 	flag.Usage = func() {
 		out := flag.CommandLine.Output()
 		fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
 		flag.PrintDefaults()
+		fmt.Fprintln(out, "\nCommands:")
+		fmt.Fprintln(out, "\tget\tprint the value of a key")
+		fmt.Fprintln(out, "\tset\tset keys in a file")
+		fmt.Fprintln(out, "\tunset\tremove keys from a file")
+		fmt.Fprintln(out, "\texport\tprint the values for a shell or another tool")
+		fmt.Fprintln(out, "\twatch\trestart a command whenever the files change")
+		fmt.Fprintln(out, "\tencrypt\tencrypt values in a file")
+		fmt.Fprintln(out, "\tdecrypt\tdecrypt values in a file")
+		fmt.Fprintln(out, "\tlint\treport mistakes and leaked credentials in the files")
+		fmt.Fprintln(out, "\tfmt\tformat files in a canonical style")
 		fmt.Fprintln(out, "\nExamples:")
 		fmt.Fprintln(out, "Multiple files:\n\t dotenv -f .env -f .another.env -- some_command -a args")
 		fmt.Fprintln(out, "Environment and paths:\n\t dotenv -e development -p ../devcfg -- some_command -a args")
+		fmt.Fprintln(out, "Replace the process, such as when running as PID 1:\n\t dotenv -exec -f .env -- some_command -a args")
+		fmt.Fprintln(out, "Only the parsed variables and PATH:\n\t dotenv -i -a PATH -f .env -- some_command -a args")
+		fmt.Fprintln(out, "Local overrides:\n\t dotenv set -f .env.local KEY=value")
 	}
 
 	flag.Parse()
 
+	options := pf.options()
+	environ := os.Environ()
+	if ignoreEnvironment {
+		allowed := allowedEnv(allowedKeys)
+		options = append(options, dotenv.Env(dotenv.MapEnvironment(allowed)))
+		environ = make([]string, 0, len(allowed))
+		for k, v := range allowed {
+			environ = append(environ, k+"="+v)
+		}
+	}
+
+	// parse everything into a map
+	vars, err := dotenv.Parse(options...)
+	if err != nil {
+		log.Fatal("loading environment files errored: ", err)
+	}
+
+	if len(flag.Args()) == 0 {
+		return
+	}
+
+	environ = commandEnv(environ, envPairs(vars))
+
+	if *execMode {
+		err = execCommand(flag.Args(), environ)
+		log.Fatal("encountered an error executing command: ", err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+
+	code, err := runCommand(flag.Args(), environ, signals)
+	if err != nil {
+		log.Fatal("encountered an error spawning command: ", err)
+	}
+	os.Exit(code)
+}
+
+func (pf *parseFlags) register(flags *flag.FlagSet) {
+	flags.Var(&pf.files, "f", "[optional] [repeatable] files with key:value pairs to set into the current environment")
+	flags.StringVar(&pf.environment, "e", "", "[optional] sets the environment to load a suite of files")
+	flags.Var(&pf.paths, "p", "[optional] [repeatable] one or more paths to search for files")
+	flags.BoolVar(&pf.overload, "o", false, "[optional] replace values that are already set in the environment")
+	flags.BoolVar(&pf.overload, "overload", false, "[optional] same as -o")
+	flags.Var(&pf.requiredKeys, "r", "[optional] [repeatable] a key that must be set")
+	flags.BoolVar(&pf.requireFiles, "require-files", false, "[optional] return an error when any of the files do not exist")
+	flags.StringVar(&pf.keyFile, "k", "", "[optional] a file with the DOTENV_PRIVATE_KEY used to decrypt values; the environment variable is used otherwise")
+}
+
+func (pf *parseFlags) options() []dotenv.ParseOption {
 	var options []dotenv.ParseOption
 
 	// parse some files
-	if len(files) > 0 {
-		options = append(options, dotenv.Files(files...))
+	if len(pf.files) > 0 {
+		options = append(options, dotenv.Files(pf.files...))
 	}
 
 	// parse a suite of files based on the provided environment
-	if environment != "" {
-		options = append(options, dotenv.EnvironmentFiles(environment))
+	if pf.environment != "" {
+		options = append(options, dotenv.EnvironmentFiles(pf.environment))
 	}
 
 	// look for files in other paths
-	if len(paths) > 0 {
-		options = append(options, dotenv.Paths(paths...))
+	if len(pf.paths) > 0 {
+		options = append(options, dotenv.Paths(pf.paths...))
 	}
 
-	// parse everything into a map
-	vars, err := dotenv.Parse(options...)
-	if err != nil {
-		log.Fatal("loading environment files errored: ", err)
+	if pf.overload {
+		options = append(options, dotenv.Overload())
 	}
 
-	if len(flag.Args()) == 0 {
-		return
+	if len(pf.requiredKeys) > 0 {
+		options = append(options, dotenv.RequiredKeys(pf.requiredKeys...))
 	}
 
-	// turn the map into a slice of "k=v" strings
-	var env []string
+	if pf.requireFiles {
+		options = append(options, dotenv.AllFilesRequired())
+	}
+
+	if pf.keyFile != "" {
+		options = append(options, dotenv.KeyFile(pf.keyFile))
+	}
+
+	return options
+}
+
+// envPairs turns the map into a slice of "k=v" strings
+func envPairs(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
 	for k, v := range vars {
 		env = append(env, k+"="+v)
 	}
 
-	err = runCommand(flag.Args(), env)
-	if err != nil {
-		log.Fatal("encountered an error spawning command: ", err)
-	}
+	return env
 }
 
-func runCommand(args, env []string) error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Dir = cwd
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), env...)
-	// is Start() and Wait() what we want here?
-	err = cmd.Start()
-	if err != nil {
-		return err
+// allowedEnv returns the values of the allowed keys that are set in the current environment
+func allowedEnv(keys []string) map[string]string {
+	allowed := make(map[string]string, len(keys))
+
+	for _, key := range keys {
+		if value, exists := os.LookupEnv(key); exists {
+			allowed[key] = value
+		}
 	}
 
-	return cmd.Wait()
+	return allowed
 }
 
 // String implements flag.Value and fmt.Stringer to allow the value to be rendered as a plain string
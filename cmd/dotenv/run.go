@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// forwardedSignals are passed on to the command while it runs
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// runCommand runs the command, passing on any signals received, and returns its exit code
+//
+// A command that is killed by a signal returns 128 plus the number of the signal, as a shell would.
+func runCommand(args, environ []string, signals <-chan os.Signal) (int, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = cwd
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = environ
+	err = cmd.Start()
+	if err != nil {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				// the command may have already exited
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return 0, err
+		}
+	}
+
+	return exitCode(cmd.ProcessState), nil
+}
+
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return state.ExitCode()
+}
+
+// commandEnv adds env to environ; values in env replace any in environ with the same key
+func commandEnv(environ, env []string) []string {
+	index := make(map[string]int, len(environ)+len(env))
+	merged := make([]string, 0, len(environ)+len(env))
+
+	for _, pairs := range [][]string{environ, env} {
+		for _, pair := range pairs {
+			key := strings.SplitN(pair, "=", 2)[0]
+			if i, exists := index[key]; exists {
+				merged[i] = pair
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, pair)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunCommand(t *testing.T) {
+	tests := map[string]struct {
+		script string
+		signal os.Signal
+		want   int
+	}{
+		"exits cleanly": {
+			script: "exit 0",
+			want:   0,
+		},
+		"returns the exit code": {
+			script: "exit 3",
+			want:   3,
+		},
+		"returns 128 plus the signal when killed": {
+			script: "kill -TERM $$",
+			want:   128 + int(syscall.SIGTERM),
+		},
+		"forwards signals": {
+			script: `trap 'exit 7' HUP; while :; do sleep 0.01; done`,
+			signal: syscall.SIGHUP,
+			want:   7,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			signals := make(chan os.Signal, 1)
+			if tt.signal != nil {
+				go func() {
+					// give the shell time to install the trap
+					time.Sleep(200 * time.Millisecond)
+					signals <- tt.signal
+				}()
+			}
+
+			got, err := runCommand([]string{"sh", "-c", tt.script}, os.Environ(), signals)
+			if err != nil {
+				t.Fatalf("runCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("runCommand() got = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandEnv(t *testing.T) {
+	got := commandEnv([]string{"A=1", "B=2"}, []string{"B=3", "C=4"})
+	want := []string{"A=1", "B=3", "C=4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commandEnv() got = %v, want %v", got, want)
+	}
+}
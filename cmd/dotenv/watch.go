@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/stackus/dotenv"
+)
+
+// runWatch runs a command and restarts it with a fresh environment whenever the files change
+func runWatch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	var pf parseFlags
+	pf.register(flags)
+	interval := flags.Duration("interval", 500*time.Millisecond, "how often the files are checked for changes")
+	debounce := flags.Duration("debounce", 300*time.Millisecond, "how long the files must go unchanged before the command is restarted")
+	grace := flags.Duration("grace", 10*time.Second, "how long the command has to exit after SIGTERM before it is killed")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s watch [flags] -- command [args...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected a command to run")
+	}
+
+	options := pf.options()
+	files, err := dotenv.FileList(options...)
+	if err != nil {
+		return err
+	}
+	watcher := newFileWatcher(files)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+
+	environ, err := watchEnviron(options)
+	if err != nil {
+		return err
+	}
+	current, err := startChild(flags.Args(), environ)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	// lastChange is the time of the most recent change that has not caused a restart yet
+	var lastChange time.Time
+	for {
+		var done <-chan struct{}
+		if current != nil {
+			done = current.done
+		}
+
+		select {
+		case sig := <-signals:
+			if current == nil {
+				os.Exit(128 + signalNumber(sig))
+			}
+			_ = current.cmd.Process.Signal(sig)
+			<-current.done
+			os.Exit(exitCode(current.cmd.ProcessState))
+		case <-done:
+			log.Printf("command exited with code %d; waiting for changes", exitCode(current.cmd.ProcessState))
+			current = nil
+		case now := <-ticker.C:
+			if watcher.changed() {
+				lastChange = now
+			}
+			if lastChange.IsZero() || now.Sub(lastChange) < *debounce {
+				continue
+			}
+			lastChange = time.Time{}
+
+			environ, err := watchEnviron(options)
+			if err != nil {
+				log.Print("loading environment files errored; keeping the running command: ", err)
+				continue
+			}
+			log.Print("environment files changed; restarting command")
+			if current != nil {
+				current.stop(*grace)
+			}
+			current, err = startChild(flags.Args(), environ)
+			if err != nil {
+				log.Print("encountered an error spawning command: ", err)
+			}
+		}
+	}
+}
+
+func watchEnviron(options []dotenv.ParseOption) ([]string, error) {
+	vars, err := dotenv.Parse(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return commandEnv(os.Environ(), envPairs(vars)), nil
+}
+
+func signalNumber(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return int(s)
+	}
+
+	return 0
+}
+
+// child is a command started by watch
+type child struct {
+	cmd *exec.Cmd
+	// done is closed once the command has exited
+	done chan struct{}
+}
+
+func startChild(args, environ []string) (*child, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = environ
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := &child{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		close(c.done)
+	}()
+
+	return c, nil
+}
+
+// stop asks the command to exit and kills it when it has not exited after the grace period
+func (c *child) stop(grace time.Duration) {
+	if err := c.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		// SIGTERM cannot be sent on every platform
+		_ = c.cmd.Process.Kill()
+	}
+
+	select {
+	case <-c.done:
+		return
+	case <-time.After(grace):
+		_ = c.cmd.Process.Kill()
+	}
+	<-c.done
+}
+
+// fileState is what was last seen of a file
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// fileWatcher polls files for changes to their contents
+//
+// The contents are only hashed when the modification time or the size of a file changes, and a file
+// whose contents end up the same is not reported as changed.
+type fileWatcher struct {
+	files  []string
+	states map[string]fileState
+}
+
+func newFileWatcher(files []string) *fileWatcher {
+	w := &fileWatcher{files: files, states: make(map[string]fileState, len(files))}
+	w.changed()
+
+	return w
+}
+
+// changed reports whether any file was created, removed or modified since the last check
+func (w *fileWatcher) changed() bool {
+	changed := false
+
+	for _, file := range w.files {
+		previous := w.states[file]
+		state, err := w.state(file, previous)
+		if err != nil {
+			// an unreadable file keeps its previous state until it can be read again
+			continue
+		}
+
+		if state.exists != previous.exists || state.hash != previous.hash {
+			changed = true
+		}
+		w.states[file] = state
+	}
+
+	return changed
+}
+
+func (w *fileWatcher) state(file string, previous fileState) (fileState, error) {
+	info, err := os.Stat(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return previous, err
+	}
+
+	state := fileState{exists: true, modTime: info.ModTime(), size: info.Size(), hash: previous.hash}
+	if previous.exists && state.modTime.Equal(previous.modTime) && state.size == previous.size {
+		return state, nil
+	}
+
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return previous, err
+	}
+	state.hash = sha256.Sum256(contents)
+
+	return state, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, ".env")
+	if err := os.WriteFile(file, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	watcher := newFileWatcher([]string{file, filepath.Join(dir, ".env.local")})
+
+	modTime := time.Now()
+	tests := []struct {
+		name string
+		edit func() error
+		want bool
+	}{
+		{
+			name: "nothing changed",
+			edit: func() error { return nil },
+			want: false,
+		},
+		{
+			name: "touched without changing the contents",
+			edit: func() error {
+				modTime = modTime.Add(time.Second)
+				return os.Chtimes(file, modTime, modTime)
+			},
+			want: false,
+		},
+		{
+			name: "contents changed",
+			edit: func() error {
+				if err := os.WriteFile(file, []byte("A=2\n"), 0o644); err != nil {
+					return err
+				}
+				modTime = modTime.Add(time.Second)
+				return os.Chtimes(file, modTime, modTime)
+			},
+			want: true,
+		},
+		{
+			name: "file created",
+			edit: func() error { return os.WriteFile(filepath.Join(dir, ".env.local"), nil, 0o644) },
+			want: true,
+		},
+		{
+			name: "file removed",
+			edit: func() error { return os.Remove(file) },
+			want: true,
+		},
+	}
+	// each step depends on the previous one, so they are run in order
+	for _, tt := range tests {
+		if err := tt.edit(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := watcher.changed(); got != tt.want {
+			t.Errorf("%s: changed() got = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,21 +1,16 @@
 package dotenv
 
 import (
+	"crypto/ecdh"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
-	"regexp"
 	"strings"
-)
-
-var (
-	varsRe         = regexp.MustCompile(`(?m)(?:^|\A)\s*(?:export\s+)?([\w.]+)(?:\s*=\s*?|:\s+?)(\s*'(?:\\'|[^'])*'|\s*"(?:\\"|[^"])*"|(?:[^\s\r\n]|[ \t]+\w)+)?\s*?(?:#.*)?(?:$|\z)`)
-	exportsRe      = regexp.MustCompile(`(?m)(?:^|\A)\s*export\s+([\w.]+)\s*(?:#.*)?(?:$|\z)`)
-	quotesRe       = regexp.MustCompile(`(?m)(?:^|\A)(?:'(?:\\'|[^'])*|"(?:\\"|[^"])*|(?:[^\s\r\n]|[ \t]+\w)+)?(["'])?(?:$|\z)`)
-	unescapeRe     = regexp.MustCompile(`\\([^$])`)
-	substitutionRe = regexp.MustCompile(`(?m)(\\)?\${?(\w+)?}?`)
+	"time"
 )
 
 type envCfg struct {
@@ -24,18 +19,36 @@ type envCfg struct {
 	overload     bool
 	requiredKeys []string
 	requireFiles bool
+	fsys         fs.FS
+	// commands enables `$(...)` command substitution
+	commands       bool
+	commandRunner  CommandRunner
+	commandTimeout time.Duration
+	env            Environment
+	schema         string
+	searchParents  *SearchParentsOpt
+	decryptionKey  *ecdh.PrivateKey
+	keyFile        string
+	providers      map[string]SecretProvider
+	secretPatterns []string
 }
 
 type envVars map[string]string
 
-func Load(options ...LoadOption) error {
-	cfg := &envCfg{
+func newEnvCfg() *envCfg {
+	return &envCfg{
 		files:        []string{".env"},
 		paths:        []string{"."},
 		overload:     false,
 		requiredKeys: []string{},
 		requireFiles: false,
+		commands:     false,
+		env:          OSEnvironment(),
 	}
+}
+
+func Load(options ...LoadOption) error {
+	cfg := newEnvCfg()
 
 	for _, option := range options {
 		err := option.loadOption(cfg)
@@ -44,17 +57,11 @@ func Load(options ...LoadOption) error {
 		}
 	}
 
-	return load(cfg)
+	return load(cfg, nil)
 }
 
 func Parse(options ...ParseOption) (map[string]string, error) {
-	cfg := &envCfg{
-		files:        []string{".env"},
-		paths:        []string{"."},
-		overload:     false,
-		requiredKeys: []string{},
-		requireFiles: false,
-	}
+	cfg := newEnvCfg()
 
 	for _, option := range options {
 		err := option.parseOption(cfg)
@@ -66,25 +73,98 @@ func Parse(options ...ParseOption) (map[string]string, error) {
 	return parse(cfg)
 }
 
-func load(cfg *envCfg) error {
+// FileList returns the files that Load() and Parse() would read using the same options
+//
+// Files are listed in the order they are read and are included whether or not they exist.
+func FileList(options ...ParseOption) ([]string, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.parseOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buildFileList(cfg)
+}
+
+// ParseReader parses the environment variables read from r
+//
+// The contents are interpolated and merged with the environment exactly as a file read by Parse()
+// would be. File related options such as Files() and Paths() have no effect.
+func ParseReader(r io.Reader, options ...ParseOption) (map[string]string, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseString(string(contents), options...)
+}
+
+// ParseBytes parses the environment variables contained in b
+//
+// See ParseReader for details.
+func ParseBytes(b []byte, options ...ParseOption) (map[string]string, error) {
+	return ParseString(string(b), options...)
+}
+
+// ParseString parses the environment variables contained in contents
+//
+// See ParseReader for details.
+func ParseString(contents string, options ...ParseOption) (map[string]string, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.parseOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fileEnvs, err := parseString(cfg, "", contents)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedEnvs := fileEnvs
+	if !cfg.overload {
+		parsedEnvs = mergeFileEnvs(make(envVars), fileEnvs, cfg.currentEnvs())
+	}
+
+	return completeEnvs(cfg, parsedEnvs)
+}
+
+// load applies the files to the environment; when snap is not nil it records the values being replaced
+func load(cfg *envCfg, snap *snapshot) error {
 	files, err := buildFileList(cfg)
 	if err != nil {
 		return err
 	}
 
 	for _, file := range files {
-		fileEnvs, err := parseFile(file, cfg.overload, cfg.requireFiles)
+		definitions, err := parseFile(cfg, file)
 		if err != nil {
 			return err
 		}
 
-		err = applyEnvs(fileEnvs, cfg.overload)
+		err = applyEnvs(cfg.env, definitionEnvs(definitions), cfg.overload, snap)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = checkRequiredKeys(cfg)
+	defaults, err := applySchema(cfg, cfg.currentEnvs())
+	if err != nil {
+		return err
+	}
+
+	err = applyEnvs(cfg.env, defaults, false, snap)
+	if err != nil {
+		return err
+	}
+
+	err = checkRequiredKeys(cfg, cfg.currentEnvs())
 	if err != nil {
 		return err
 	}
@@ -93,39 +173,61 @@ func load(cfg *envCfg) error {
 }
 
 func parse(cfg *envCfg) (envVars, error) {
-	parsedEnvs := make(envVars)
-
-	files, err := buildFileList(cfg)
+	details, err := parseDetailed(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, file := range files {
-		fileEnvs, err := parseFile(file, cfg.overload, cfg.requireFiles)
-		if err != nil {
-			return nil, err
-		}
+	return completeEnvs(cfg, detailEnvs(details))
+}
 
-		currentEnv := mergeEnvs(parsedEnvs, systemEnvs())
-		appliedEnvs := make(envVars)
+// detailEnvs returns the final value of each key
+func detailEnvs(details map[string]Detail) envVars {
+	envs := make(envVars, len(details))
 
-		for key, value := range fileEnvs {
-			if currentValue, exists := currentEnv[key]; !exists {
-				appliedEnvs[key] = value
-			} else {
-				appliedEnvs[key] = currentValue
-			}
-		}
+	for key, detail := range details {
+		envs[key] = detail.Value
+	}
+
+	return envs
+}
 
-		parsedEnvs = mergeEnvs(parsedEnvs, appliedEnvs)
+// completeEnvs adds the schema defaults to the parsed values and checks that the required keys are set
+func completeEnvs(cfg *envCfg, parsedEnvs envVars) (envVars, error) {
+	// keys that are set in the environment satisfy the schema just as they do when loading
+	defaults, err := applySchema(cfg, mergeEnvs(cfg.currentEnvs(), parsedEnvs))
+	if err != nil {
+		return nil, err
+	}
+	parsedEnvs = mergeEnvs(parsedEnvs, defaults)
+
+	err = checkRequiredKeys(cfg, mergeEnvs(cfg.currentEnvs(), parsedEnvs))
+	if err != nil {
+		return nil, err
 	}
 
 	return parsedEnvs, nil
 }
 
-func checkRequiredKeys(cfg *envCfg) error {
-	currentEnv := systemEnvs()
+// mergeFileEnvs adds the values parsed from a file to the values parsed so far
+//
+// Values that are already set, either by a previous file or in the environment, are kept.
+func mergeFileEnvs(parsedEnvs, fileEnvs, systemEnvs envVars) envVars {
+	currentEnv := mergeEnvs(parsedEnvs, systemEnvs)
+	appliedEnvs := make(envVars)
+
+	for key, value := range fileEnvs {
+		if currentValue, exists := currentEnv[key]; !exists {
+			appliedEnvs[key] = value
+		} else {
+			appliedEnvs[key] = currentValue
+		}
+	}
+
+	return mergeEnvs(parsedEnvs, appliedEnvs)
+}
 
+func checkRequiredKeys(cfg *envCfg, currentEnv envVars) error {
 	if len(cfg.requiredKeys) > 0 {
 		missingKeys := make([]string, 0)
 		for _, key := range cfg.requiredKeys {
@@ -141,12 +243,15 @@ func checkRequiredKeys(cfg *envCfg) error {
 	return nil
 }
 
-func applyEnvs(envs envVars, overload bool) error {
-	currentEnv := systemEnvs()
+func applyEnvs(env Environment, envs envVars, overload bool, snap *snapshot) error {
+	currentEnv := environmentEnvs(env)
 
 	for key, value := range envs {
 		if _, exists := currentEnv[key]; !exists || overload {
-			err := os.Setenv(key, value)
+			if snap != nil {
+				snap.record(key, currentEnv)
+			}
+			err := env.Set(key, value)
 			if err != nil {
 				return err
 			}
@@ -160,94 +265,243 @@ func buildFileList(cfg *envCfg) ([]string, error) {
 	envFiles := make([]string, 0)
 
 	for _, path := range cfg.paths {
-		absPath, err := filepath.Abs(path)
+		absPath, err := cfg.absPath(path)
 		if err != nil {
 			return nil, err
 		}
-		info, statErr := os.Stat(absPath)
-		if !(!errors.Is(statErr, fs.ErrNotExist) && info.IsDir()) {
+		info, statErr := cfg.stat(absPath)
+		if statErr != nil || !info.IsDir() {
 			return nil, fmt.Errorf("path does not exist or is not a directory: %s", path)
 		}
+		if cfg.searchParents != nil {
+			absPath = cfg.searchParent(absPath)
+		}
 
 		for _, envFile := range cfg.files {
-			envFiles = append(envFiles, filepath.Join(absPath, envFile))
+			envFiles = append(envFiles, cfg.join(absPath, envFile))
 		}
 	}
 
 	return envFiles, nil
 }
 
-func parseFile(fileName string, overload, mustExist bool) (envVars, error) {
-	if info, err := os.Stat(fileName); errors.Is(err, fs.ErrNotExist) || info.IsDir() {
-		if errors.Is(err, fs.ErrNotExist) && mustExist {
+// searchParent returns the closest directory, starting with dir, that contains any of the files
+//
+// dir is returned when none of the directories searched contain any of the files.
+func (c *envCfg) searchParent(dir string) string {
+	current := dir
+	for depth := 0; c.searchParents.maxDepth <= 0 || depth <= c.searchParents.maxDepth; depth++ {
+		for _, envFile := range c.files {
+			if info, err := c.stat(c.join(current, envFile)); err == nil && !info.IsDir() {
+				return current
+			}
+		}
+		for _, marker := range c.searchParents.markers {
+			if _, err := c.stat(c.join(current, marker)); err == nil {
+				return dir
+			}
+		}
+
+		parent := c.dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return dir
+}
+
+func parseFile(cfg *envCfg, fileName string) ([]Definition, error) {
+	if info, err := cfg.stat(fileName); err != nil || info.IsDir() {
+		if errors.Is(err, fs.ErrNotExist) && cfg.requireFiles {
 			return nil, fmt.Errorf("environment variables file was not found: %s", fileName)
 		}
-		return envVars{}, nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return []Definition{}, nil
 	}
 
-	contents, err := os.ReadFile(fileName)
+	contents, err := cfg.readFile(fileName)
 	if err != nil {
 		return nil, err
 	}
 
-	return parseString(string(contents), overload)
+	return parseDefinitions(cfg, fileName, string(contents))
 }
 
-func parseString(contents string, overload bool) (envVars, error) {
-	matches := varsRe.FindAllStringSubmatch(contents, -1)
+func parseString(cfg *envCfg, fileName, contents string) (envVars, error) {
+	definitions, err := parseDefinitions(cfg, fileName, contents)
+	if definitions == nil {
+		return nil, err
+	}
+
+	return definitionEnvs(definitions), err
+}
 
+// parseDefinitions parses the contents of a file into the list of definitions in the order they were read
+func parseDefinitions(cfg *envCfg, fileName, contents string) ([]Definition, error) {
+	statements, err := lex(fileName, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make([]Definition, 0, len(statements))
 	parsedEnvs := make(envVars)
+	currentEnv := cfg.currentEnvs()
+	lookup := func(key string) (string, bool) {
+		first, second := currentEnv, parsedEnvs
+		if cfg.overload {
+			first, second = parsedEnvs, currentEnv
+		}
+		if value, exists := first[key]; exists {
+			return value, true
+		}
+		value, exists := second[key]
+		return value, exists
+	}
 
-	for _, match := range matches {
-		parsedEnvs[match[1]] = parseValue(match[2], combineEnvs(parsedEnvs, overload))
+	var command func(string) (string, error)
+	if cfg.commands {
+		command = func(cmd string) (string, error) {
+			return runCommand(cfg, fileName, cmd)
+		}
 	}
 
-	exports := exportsRe.FindAllStringSubmatch(varsRe.ReplaceAllString(contents, ""), -1)
-	for _, export := range exports {
-		if export[1] != "" {
-			if _, exists := parsedEnvs[export[1]]; !exists {
-				return parsedEnvs, fmt.Errorf("line %s has an unset variable", export[0])
+	// secret is true when a comment marked the next assignment as secret
+	secret := false
+	for _, st := range statements {
+		switch st.kind {
+		case commentStatement:
+			secret = secret || isSecretComment(contents, st)
+			continue
+		case assignStatement:
+		default:
+			secret = false
+			continue
+		}
+
+		value, err := decodeValue(contents[st.valueStart:st.valueEnd], st.quote, lookup, command)
+		if err != nil {
+			var requiredErr *RequiredVariableError
+			if errors.As(err, &requiredErr) {
+				requiredErr.File, requiredErr.Line, requiredErr.Key = fileName, st.line, st.key
 			}
+			var commandErr *CommandError
+			if errors.As(err, &commandErr) {
+				commandErr.File, commandErr.Line, commandErr.Key = fileName, st.line, st.key
+			}
+			return nil, err
 		}
+		if IsEncrypted(value) {
+			value, err = decryptDefinition(cfg, value)
+			if err != nil {
+				return nil, &DecryptionError{File: fileName, Line: st.line, Key: st.key, Err: err}
+			}
+		}
+		reference := value
+		value, err = resolveSecret(cfg, reference)
+		if err != nil {
+			return nil, &SecretError{File: fileName, Line: st.line, Key: st.key, Reference: reference, Err: err}
+		}
+		parsedEnvs[st.key] = value
+		definitions = append(definitions, Definition{
+			Key:    st.key,
+			Value:  value,
+			File:   fileName,
+			Line:   st.line,
+			Secret: secret || st.commentStart != -1 && isSecretComment(contents, st),
+		})
+		secret = false
 	}
 
-	return parsedEnvs, nil
+	for _, st := range statements {
+		if st.kind == exportStatement {
+			if _, exists := parsedEnvs[st.key]; !exists {
+				text := strings.TrimSpace(contents[st.start:st.end])
+				return definitions, newParseError(fileName, contents, st.start+strings.Index(contents[st.start:st.end], text), text, "unset variable")
+			}
+		}
+	}
+
+	return definitions, nil
 }
 
-func parseValue(value string, envs envVars) string {
-	value = strings.Trim(value, " \t\f")
-	m := quotesRe.FindStringSubmatch(value)
-	quote := m[1]
-	value = strings.Trim(value, quote)
-	switch quote {
-	case `"`:
-		value = strings.ReplaceAll(strings.ReplaceAll(value, `\n`, "\n"), `\r`, "\r")
-		fallthrough
-	case ``:
-		value = unescapeRe.ReplaceAllString(value, "$1")
-	}
-
-	if quote != "'" {
-		value = substitutionRe.ReplaceAllStringFunc(value, func(s string) string {
-			submatch := substitutionRe.FindStringSubmatch(s)
-
-			if submatch[1] != "" {
-				return submatch[0][1:]
-			}
+// decryptDefinition decrypts value when a private key was provided; otherwise value is returned as it is
+func decryptDefinition(cfg *envCfg, value string) (string, error) {
+	key, err := cfg.privateKey()
+	if err != nil || key == nil {
+		return value, err
+	}
 
-			if submatch[2] == "" {
-				return s
-			}
+	return decryptValue(key, value)
+}
 
-			if val, exists := envs[submatch[2]]; exists {
-				return val
-			}
+// definitionEnvs returns the value of each key; later definitions replace earlier ones
+func definitionEnvs(definitions []Definition) envVars {
+	envs := make(envVars, len(definitions))
 
-			return ""
-		})
+	for _, definition := range definitions {
+		envs[definition.Key] = definition.Value
+	}
+
+	return envs
+}
+
+// absPath returns the absolute form of path; paths within a fs.FS are cleaned instead
+func (c *envCfg) absPath(path string) (string, error) {
+	if c.fsys == nil {
+		return filepath.Abs(path)
+	}
+
+	path = pathpkg.Clean(filepath.ToSlash(path))
+	if !fs.ValidPath(path) {
+		return "", fmt.Errorf("path is not valid within the filesystem: %s", path)
+	}
+
+	return path, nil
+}
+
+func (c *envCfg) join(elem ...string) string {
+	if c.fsys == nil {
+		return filepath.Join(elem...)
+	}
+
+	return pathpkg.Join(elem...)
+}
+
+func (c *envCfg) dir(path string) string {
+	if c.fsys == nil {
+		return filepath.Dir(path)
+	}
+
+	return pathpkg.Dir(path)
+}
+
+func (c *envCfg) stat(name string) (fs.FileInfo, error) {
+	if c.fsys == nil {
+		return os.Stat(name)
 	}
 
-	return value
+	return fs.Stat(c.fsys, name)
+}
+
+func (c *envCfg) readFile(name string) ([]byte, error) {
+	if c.fsys == nil {
+		return os.ReadFile(name)
+	}
+
+	return fs.ReadFile(c.fsys, name)
+}
+
+// currentEnvs returns the variables in the configured Environment
+func (c *envCfg) currentEnvs() envVars {
+	if c.env == nil {
+		return systemEnvs()
+	}
+
+	return environmentEnvs(c.env)
 }
 
 func systemEnvs() envVars {
@@ -261,14 +515,6 @@ func systemEnvs() envVars {
 	return currentEnv
 }
 
-func combineEnvs(parsedEnvs envVars, overload bool) envVars {
-	if overload {
-		return mergeEnvs(systemEnvs(), parsedEnvs)
-	}
-
-	return mergeEnvs(parsedEnvs, systemEnvs())
-}
-
 func mergeEnvs(envs ...envVars) envVars {
 	mergedEnvs := make(envVars)
 
@@ -0,0 +1,78 @@
+package dotenv
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const defaultCommandTimeout = 10 * time.Second
+
+// CommandRunner runs the commands found within `$(...)` command substitutions
+type CommandRunner interface {
+	// RunCommand runs command from the directory dir and returns what it wrote to stdout
+	//
+	// dir is empty when the value was not read from a file on the operating system filesystem.
+	RunCommand(ctx context.Context, dir, command string) (string, error)
+}
+
+// CommandRunnerFunc is an adapter to allow the use of ordinary functions as a CommandRunner
+type CommandRunnerFunc func(ctx context.Context, dir, command string) (string, error)
+
+// RunCommand calls f(ctx, dir, command)
+func (f CommandRunnerFunc) RunCommand(ctx context.Context, dir, command string) (string, error) {
+	return f(ctx, dir, command)
+}
+
+// shellRunner runs commands with the system shell
+type shellRunner struct{}
+
+func (shellRunner) RunCommand(ctx context.Context, dir, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+// runCommand runs a command substitution found in fileName and returns its output
+//
+// Trailing newlines are removed from the output just as a shell would.
+func runCommand(cfg *envCfg, fileName, command string) (string, error) {
+	runner := cfg.commandRunner
+	if runner == nil {
+		runner = shellRunner{}
+	}
+
+	timeout := cfg.commandTimeout
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+
+	dir := ""
+	if fileName != "" && cfg.fsys == nil {
+		dir = filepath.Dir(fileName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := runner.RunCommand(ctx, dir, command)
+	if err != nil {
+		return "", &CommandError{Command: command, Err: err}
+	}
+
+	return strings.TrimRight(output, "\r\n"), nil
+}
@@ -0,0 +1,125 @@
+package dotenv
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCommandSubstitution(t *testing.T) {
+	runner := CommandRunnerFunc(func(ctx context.Context, dir, command string) (string, error) {
+		if command == "fail" {
+			return "", errors.New("exit status 1")
+		}
+		return "<" + command + ">\n\n", nil
+	})
+
+	type args struct {
+		contents string
+		options  []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		"disabled by default": {
+			args:    args{contents: "FOO=$(whoami)", options: []ParseOption{CommandSubstitutionRunner(runner)}},
+			want:    envVars{"FOO": "$(whoami)"},
+			wantErr: false,
+		},
+		"substitutes unquoted values": {
+			args:    args{contents: "FOO=$(whoami)", options: []ParseOption{AllowCommandSubstitution(), CommandSubstitutionRunner(runner)}},
+			want:    envVars{"FOO": "<whoami>"},
+			wantErr: false,
+		},
+		"substitutes double quoted values": {
+			args:    args{contents: `FOO="user $(echo \"(a)\" ')') done"`, options: []ParseOption{AllowCommandSubstitution(), CommandSubstitutionRunner(runner)}},
+			want:    envVars{"FOO": `user <echo \"(a)\" ')'> done`},
+			wantErr: false,
+		},
+		"does not substitute single quoted values": {
+			args:    args{contents: `FOO='$(whoami)'`, options: []ParseOption{AllowCommandSubstitution(), CommandSubstitutionRunner(runner)}},
+			want:    envVars{"FOO": "$(whoami)"},
+			wantErr: false,
+		},
+		"does not substitute escaped commands": {
+			args:    args{contents: `FOO="\$(whoami)"`, options: []ParseOption{AllowCommandSubstitution(), CommandSubstitutionRunner(runner)}},
+			want:    envVars{"FOO": "$(whoami)"},
+			wantErr: false,
+		},
+		"returns command errors": {
+			args:    args{contents: "FOO=$(fail)", options: []ParseOption{AllowCommandSubstitution(), CommandSubstitutionRunner(runner)}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			got, err := ParseString(tt.args.contents, tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseString() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandSubstitutionRunner(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("\nFOO=$(fail)"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotDir string
+	var gotDeadline time.Duration
+	runner := CommandRunnerFunc(func(ctx context.Context, dir, command string) (string, error) {
+		gotDir = dir
+		if deadline, ok := ctx.Deadline(); ok {
+			gotDeadline = time.Until(deadline)
+		}
+		return "", errors.New("exit status 1")
+	})
+
+	os.Clearenv()
+	_, err := Parse(Paths(dir), AllowCommandSubstitution(), CommandSubstitutionRunner(runner), CommandSubstitutionTimeout(time.Minute))
+
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) {
+		t.Fatalf("Parse() error = %v, want a *CommandError", err)
+	}
+	if commandErr.File != filepath.Join(dir, ".env") || commandErr.Line != 2 || commandErr.Key != "FOO" || commandErr.Command != "fail" {
+		t.Errorf("Parse() error = %#v", commandErr)
+	}
+	if gotDir != dir {
+		t.Errorf("RunCommand() dir = %q, want %q", gotDir, dir)
+	}
+	if gotDeadline <= 50*time.Second || gotDeadline > time.Minute {
+		t.Errorf("RunCommand() deadline = %v, want about %v", gotDeadline, time.Minute)
+	}
+}
+
+func TestShellRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+
+	// other tests clear the environment
+	t.Setenv("PATH", "/usr/local/bin:/usr/bin:/bin")
+	got, err := ParseString("FOO=$(printf 'hello\\n')", AllowCommandSubstitution())
+	if err != nil {
+		t.Fatalf("ParseString() error = %v", err)
+	}
+	if want := map[string]string{"FOO": "hello"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseString() got = %v, want %v", got, want)
+	}
+}
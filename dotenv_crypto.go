@@ -0,0 +1,219 @@
+package dotenv
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// encryptedPrefix marks values that were encrypted by EncryptValue
+	encryptedPrefix = "enc:"
+	// PublicKeyName is the key holding the public key used to encrypt the values in a file
+	PublicKeyName = "DOTENV_PUBLIC_KEY"
+	// PrivateKeyName is the key holding the private key used to decrypt values, either in the
+	// environment or in a key file
+	PrivateKeyName = "DOTENV_PRIVATE_KEY"
+)
+
+// GenerateKey returns a new hex encoded X25519 key pair for EncryptValue and DecryptValue
+func GenerateKey() (publicKey, privateKey string, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(key.PublicKey().Bytes()), hex.EncodeToString(key.Bytes()), nil
+}
+
+// PublicKey returns the hex encoded public key that matches the hex encoded privateKey
+func PublicKey(privateKey string) (string, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// IsEncrypted reports whether value was encrypted by EncryptValue
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptValue encrypts value so that it can only be decrypted with the private key matching publicKey
+//
+// A new X25519 key pair is created for each value and the key shared with publicKey is used to seal the
+// value with AES-256-GCM. The result starts with "enc:" and is safe to use unquoted.
+func EncryptValue(publicKey, value string) (string, error) {
+	recipient, err := parsePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newValueCipher(shared, ephemeral.PublicKey(), recipient)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := append(ephemeral.PublicKey().Bytes(), nonce...)
+	sealed = aead.Seal(sealed, nonce, []byte(value), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue decrypts a value returned by EncryptValue with the hex encoded privateKey
+func DecryptValue(privateKey, value string) (string, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return decryptValue(key, value)
+}
+
+func decryptValue(key *ecdh.PrivateKey, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	keySize := len(key.PublicKey().Bytes())
+	if len(sealed) < keySize {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(sealed[:keySize])
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	shared, err := key.ECDH(ephemeral)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	aead, err := newValueCipher(shared, ephemeral, key.PublicKey())
+	if err != nil {
+		return "", err
+	}
+	sealed = sealed[keySize:]
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("the value was not encrypted with the public key matching the private key")
+	}
+
+	return string(plain), nil
+}
+
+// newValueCipher derives the AES-256-GCM cipher for a value from the shared X25519 key
+//
+// The shared key is hashed along with the ephemeral public key of the value and the public key of the
+// recipient so that the cipher is bound to both keys.
+func newValueCipher(shared []byte, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	hash := sha256.New()
+	hash.Write(shared)
+	hash.Write(ephemeral.Bytes())
+	hash.Write(recipient.Bytes())
+
+	block, err := aes.NewCipher(hash.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func parsePublicKey(publicKey string) (*ecdh.PublicKey, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	key, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	return key, nil
+}
+
+func parsePrivateKey(privateKey string) (*ecdh.PrivateKey, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	return key, nil
+}
+
+// privateKey returns the key used to decrypt values, or nil when no key was provided
+//
+// The key is taken from the DecryptionKey() option, then the KeyFile() option and finally the
+// DOTENV_PRIVATE_KEY environment variable.
+func (c *envCfg) privateKey() (*ecdh.PrivateKey, error) {
+	if c.decryptionKey != nil {
+		return c.decryptionKey, nil
+	}
+
+	privateKey, exists := "", false
+	switch {
+	case c.keyFile != "":
+		fileName, err := c.absPath(c.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := c.readFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := parseDocument(string(contents))
+		if err != nil {
+			return nil, err
+		}
+		if privateKey, exists = doc.Get(PrivateKeyName); !exists {
+			return nil, fmt.Errorf("%s is not set in the key file %s", PrivateKeyName, c.keyFile)
+		}
+	default:
+		if privateKey, exists = c.currentEnvs()[PrivateKeyName]; !exists {
+			return nil, nil
+		}
+	}
+
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	c.decryptionKey = key
+
+	return key, nil
+}
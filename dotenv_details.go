@@ -0,0 +1,103 @@
+package dotenv
+
+// Definition is a value assigned to a key by a file
+type Definition struct {
+	Key   string
+	Value string
+	// File is the path of the file containing the definition; it is empty when no file was involved
+	File string
+	// Line is the 1-based line number of the definition
+	Line int
+	// Secret is true when the definition is marked with an `@secret` comment
+	Secret bool
+}
+
+// Detail describes the final value of a key and where that value came from
+type Detail struct {
+	Key   string
+	Value string
+	// File and Line locate the definition that set Value; they are empty when FromEnvironment is true
+	File string
+	Line int
+	// FromEnvironment is true when the key was already set in the environment, which takes priority over every
+	// file unless Overload() is used
+	FromEnvironment bool
+	// Shadowed lists the definitions that were ignored, from the highest priority to the lowest
+	Shadowed []Definition
+	// Secret is true when any of the definitions of the key are marked with an `@secret` comment
+	Secret bool
+}
+
+// ParseDetailed works like Parse() but reports where the value of each key came from
+func ParseDetailed(options ...ParseOption) (map[string]Detail, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.parseOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parseDetailed(cfg)
+}
+
+func parseDetailed(cfg *envCfg) (map[string]Detail, error) {
+	details := make(map[string]Detail)
+
+	files, err := buildFileList(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.overload {
+		// later files replace the values of earlier ones, just as they do when loading
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+
+	currentEnv := cfg.currentEnvs()
+	for _, file := range files {
+		definitions, err := parseFile(cfg, file)
+		if err != nil {
+			return nil, err
+		}
+
+		// the last definition within a file takes priority
+		for i := len(definitions) - 1; i >= 0; i-- {
+			definition := definitions[i]
+
+			detail, exists := details[definition.Key]
+			switch {
+			case exists:
+				detail.Shadowed = append(detail.Shadowed, definition)
+			case hasKey(currentEnv, definition.Key) && !cfg.overload:
+				detail = Detail{
+					Key:             definition.Key,
+					Value:           currentEnv[definition.Key],
+					FromEnvironment: true,
+					Shadowed:        []Definition{definition},
+				}
+			default:
+				detail = Detail{
+					Key:      definition.Key,
+					Value:    definition.Value,
+					File:     definition.File,
+					Line:     definition.Line,
+					Shadowed: []Definition{},
+				}
+			}
+			detail.Secret = detail.Secret || definition.Secret
+			details[definition.Key] = detail
+		}
+	}
+
+	return details, nil
+}
+
+func hasKey(envs envVars, key string) bool {
+	_, exists := envs[key]
+
+	return exists
+}
@@ -0,0 +1,250 @@
+package dotenv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Document is the contents of an environment variables file that can be edited in place
+//
+// Comments, blank lines, `export` prefixes, separators and quoting are kept exactly as they were read;
+// only the statements that are changed are rewritten. The zero value is an empty document.
+type Document struct {
+	prefix  string
+	entries []documentEntry
+}
+
+// documentEntry is a single statement along with its line ending
+type documentEntry struct {
+	text string
+	// st holds offsets relative to the start of text
+	st statement
+}
+
+// ParseDocument reads the contents of an environment variables file from r into a Document
+func ParseDocument(r io.Reader) (*Document, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDocument(string(contents))
+}
+
+func parseDocument(contents string) (*Document, error) {
+	statements, err := lex("", contents)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{entries: make([]documentEntry, len(statements))}
+	if len(statements) > 0 {
+		doc.prefix = contents[:statements[0].start]
+	}
+	for i, st := range statements {
+		end := len(contents)
+		if i+1 < len(statements) {
+			end = statements[i+1].start
+		}
+		doc.entries[i] = documentEntry{
+			text: contents[st.start:end],
+			st:   shiftStatement(st, -st.start),
+		}
+	}
+
+	return doc, nil
+}
+
+// Get returns the value of key as written, without expanding any variables
+//
+// When key is defined more than once the last definition is used, just as it would be when parsed.
+func (d *Document) Get(key string) (string, bool) {
+	i := d.lastAssignment(key)
+	if i == -1 {
+		return "", false
+	}
+
+	e := d.entries[i]
+	value, err := decodeValue(e.text[e.st.valueStart:e.st.valueEnd], e.st.quote, nil, nil)
+	if err != nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+// Set sets the value of key
+//
+// The last definition of key is updated and keeps its quoting whenever the new value allows it.
+// Keys that are not defined are added to the end of the document.
+func (d *Document) Set(key, value string) error {
+	if !isKey(key) {
+		return fmt.Errorf("invalid key: %q", key)
+	}
+
+	i := d.lastAssignment(key)
+	if i == -1 {
+		d.appendEntry(key + "=" + quoteValue(value))
+		return nil
+	}
+
+	e := d.entries[i]
+	quoted := requoteValue(value, e.st.quote)
+	if e.st.valueStart == e.st.valueEnd && e.st.valueEnd < len(e.text) && e.text[e.st.valueEnd] == '#' {
+		// keep the inline comment that directly follows an empty value
+		quoted += " "
+	}
+	text := e.text[:e.st.valueStart] + quoted + e.text[e.st.valueEnd:]
+
+	entry, err := parseEntry(text)
+	if err != nil {
+		return err
+	}
+	d.entries[i] = entry
+
+	return nil
+}
+
+// Delete removes every definition of key, including any `export KEY` lines, and reports whether any were found
+func (d *Document) Delete(key string) bool {
+	entries := d.entries[:0]
+	for _, e := range d.entries {
+		if (e.st.kind == assignStatement || e.st.kind == exportStatement) && e.st.key == key {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	deleted := len(entries) != len(d.entries)
+	d.entries = entries
+
+	return deleted
+}
+
+// Keys returns the keys defined in the document in the order they first appear
+func (d *Document) Keys() []string {
+	keys := make([]string, 0, len(d.entries))
+	seen := make(map[string]bool, len(d.entries))
+
+	for _, e := range d.entries {
+		if e.st.kind == assignStatement && !seen[e.st.key] {
+			seen[e.st.key] = true
+			keys = append(keys, e.st.key)
+		}
+	}
+
+	return keys
+}
+
+// Count returns the number of times key is defined in the document
+func (d *Document) Count(key string) int {
+	count := 0
+	for _, e := range d.entries {
+		if e.st.kind == assignStatement && e.st.key == key {
+			count++
+		}
+	}
+
+	return count
+}
+
+// WriteTo writes the contents of the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString(d.prefix)
+	for _, e := range d.entries {
+		b.WriteString(e.text)
+	}
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+func (d *Document) lastAssignment(key string) int {
+	for i := len(d.entries) - 1; i >= 0; i-- {
+		if d.entries[i].st.kind == assignStatement && d.entries[i].st.key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// appendEntry adds a statement to the end of the document using the line ending the document already uses
+func (d *Document) appendEntry(text string) {
+	newline := "\n"
+	for _, e := range d.entries {
+		if ending := lineEnding(e.text); ending != "" {
+			newline = ending
+			break
+		}
+	}
+
+	if n := len(d.entries); n > 0 && lineEnding(d.entries[n-1].text) == "" {
+		d.entries[n-1].text += newline
+	}
+
+	// text is always a single valid statement
+	entry, _ := parseEntry(text + newline)
+	d.entries = append(d.entries, entry)
+}
+
+// parseEntry lexes the text of a single statement
+func parseEntry(text string) (documentEntry, error) {
+	statements, err := lex("", text)
+	if err != nil {
+		return documentEntry{}, err
+	}
+	if len(statements) != 1 {
+		return documentEntry{}, fmt.Errorf("expected a single statement: %q", text)
+	}
+
+	return documentEntry{text: text, st: statements[0]}, nil
+}
+
+// requoteValue quotes value using the quote character the previous value used whenever it can
+func requoteValue(value string, quote byte) string {
+	switch quote {
+	case '\'':
+		if !strings.Contains(value, "'") && !strings.HasSuffix(value, `\`) {
+			return "'" + value + "'"
+		}
+	case '"':
+		if quoted := quoteValue(value); quoted == "" || quoted[0] != '"' {
+			return `"` + quoted + `"`
+		}
+	}
+
+	return quoteValue(value)
+}
+
+// lineEnding returns the line ending that text ends with
+func lineEnding(text string) string {
+	switch {
+	case strings.HasSuffix(text, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(text, "\n"):
+		return "\n"
+	case strings.HasSuffix(text, "\r"):
+		return "\r"
+	}
+
+	return ""
+}
+
+// shiftStatement moves the offsets of st by delta
+func shiftStatement(st statement, delta int) statement {
+	st.start += delta
+	st.end += delta
+	st.keyStart += delta
+	st.keyEnd += delta
+	st.valueStart += delta
+	st.valueEnd += delta
+	if st.commentStart != -1 {
+		st.commentStart += delta
+	}
+
+	return st
+}
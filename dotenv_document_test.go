@@ -0,0 +1,154 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDocument = "\ufeff# database settings\r\n" +
+	"export DB_HOST=localhost # the host\r\n" +
+	"DB_PASSWORD='secret'\r\n" +
+	"\r\n" +
+	"DB_NAME: app\r\n" +
+	"DB_OPTIONS=\"sslmode=disable\r\nconnect_timeout=10\"\r\n" +
+	"DB_USER= # set me\r\n" +
+	"export DB_NAME\r\n" +
+	"DB_HOST=override"
+
+func TestDocument(t *testing.T) {
+	tests := map[string]struct {
+		edit func(d *Document) error
+		want string
+	}{
+		"keeps every byte when unchanged": {
+			edit: func(d *Document) error { return nil },
+			want: testDocument,
+		},
+		"sets the last definition": {
+			edit: func(d *Document) error { return d.Set("DB_HOST", "db.internal") },
+			want: strings.Replace(testDocument, "DB_HOST=override", "DB_HOST=db.internal", 1),
+		},
+		"keeps single quotes": {
+			edit: func(d *Document) error { return d.Set("DB_PASSWORD", "new secret") },
+			want: strings.Replace(testDocument, "'secret'", "'new secret'", 1),
+		},
+		"switches from single quotes when needed": {
+			edit: func(d *Document) error { return d.Set("DB_PASSWORD", "it's") },
+			want: strings.Replace(testDocument, "'secret'", `"it's"`, 1),
+		},
+		"keeps double quotes": {
+			edit: func(d *Document) error { return d.Set("DB_OPTIONS", "sslmode=require") },
+			want: strings.Replace(testDocument, "\"sslmode=disable\r\nconnect_timeout=10\"", `"sslmode=require"`, 1),
+		},
+		"sets empty double quoted values": {
+			edit: func(d *Document) error { return d.Set("DB_OPTIONS", "") },
+			want: strings.Replace(testDocument, "\"sslmode=disable\r\nconnect_timeout=10\"", `""`, 1),
+		},
+		"sets empty single quoted values": {
+			edit: func(d *Document) error { return d.Set("DB_PASSWORD", "") },
+			want: strings.Replace(testDocument, "'secret'", "''", 1),
+		},
+		"sets empty unquoted values": {
+			edit: func(d *Document) error { return d.Set("DB_HOST", "") },
+			want: strings.Replace(testDocument, "DB_HOST=override", "DB_HOST=", 1),
+		},
+		"keeps the yaml style": {
+			edit: func(d *Document) error { return d.Set("DB_NAME", "other app") },
+			want: strings.Replace(testDocument, "DB_NAME: app", `DB_NAME: "other app"`, 1),
+		},
+		"keeps comments after empty values": {
+			edit: func(d *Document) error { return d.Set("DB_USER", "admin") },
+			want: strings.Replace(testDocument, "DB_USER= # set me", "DB_USER= admin # set me", 1),
+		},
+		"appends new keys with the same line endings": {
+			edit: func(d *Document) error { return d.Set("DB_PORT", "5432") },
+			want: testDocument + "\r\nDB_PORT=5432\r\n",
+		},
+		"deletes every definition": {
+			edit: func(d *Document) error {
+				d.Delete("DB_HOST")
+				d.Delete("DB_NAME")
+				return nil
+			},
+			want: "\ufeff# database settings\r\n" +
+				"DB_PASSWORD='secret'\r\n" +
+				"\r\n" +
+				"DB_OPTIONS=\"sslmode=disable\r\nconnect_timeout=10\"\r\n" +
+				"DB_USER= # set me\r\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := ParseDocument(strings.NewReader(testDocument))
+			if err != nil {
+				t.Fatalf("ParseDocument() error = %v", err)
+			}
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+			var b strings.Builder
+			if _, err := doc.WriteTo(&b); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("WriteTo() got = %q, want %q", got, tt.want)
+			}
+			if _, err := parseString(&envCfg{}, "", b.String()); err != nil {
+				t.Errorf("parseString() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDocumentGet(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(testDocument + "\nREF=\"${DB_HOST}\\n\""))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		key       string
+		want      string
+		wantFound bool
+	}{
+		"returns the last definition": {key: "DB_HOST", want: "override", wantFound: true},
+		"removes quotes":              {key: "DB_OPTIONS", want: "sslmode=disable\r\nconnect_timeout=10", wantFound: true},
+		"does not expand variables":   {key: "REF", want: "${DB_HOST}\n", wantFound: true},
+		"returns empty values":        {key: "DB_USER", want: "", wantFound: true},
+		"reports missing keys":        {key: "DB_PORT", want: "", wantFound: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, found := doc.Get(tt.key)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("Get() got = %q, %v, want %q, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+
+	want := []string{"DB_HOST", "DB_PASSWORD", "DB_NAME", "DB_OPTIONS", "DB_USER", "REF"}
+	if keys := doc.Keys(); !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys() got = %v, want %v", keys, want)
+	}
+	if count := doc.Count("DB_HOST"); count != 2 {
+		t.Errorf("Count() got = %d, want 2", count)
+	}
+}
+
+func TestDocumentZeroValue(t *testing.T) {
+	var doc Document
+	if err := doc.Set("FOO", "bar baz"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("NOT VALID", "bar"); err == nil {
+		t.Errorf("Set() error = nil, want an error for an invalid key")
+	}
+	var b strings.Builder
+	if _, err := doc.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if got, want := b.String(), "FOO=\"bar baz\"\n"; got != want {
+		t.Errorf("WriteTo() got = %q, want %q", got, want)
+	}
+}
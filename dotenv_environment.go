@@ -0,0 +1,169 @@
+package dotenv
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Environment is a set of environment variables that values are loaded into and checked against
+//
+// The process environment is used unless the Env() option provides another Environment.
+type Environment interface {
+	// Lookup returns the value of key and whether it is set
+	Lookup(key string) (string, bool)
+	// Set sets the value of key
+	Set(key, value string) error
+	// Unset removes key
+	Unset(key string) error
+	// Keys returns the keys that are set
+	Keys() []string
+}
+
+// OSEnvironment returns the Environment of the current process
+func OSEnvironment() Environment {
+	return osEnvironment{}
+}
+
+// MapEnvironment returns an Environment that reads and writes the variables in m
+//
+// m must not be nil.
+func MapEnvironment(m map[string]string) Environment {
+	return mapEnvironment(m)
+}
+
+// SliceEnvironment returns an Environment that reads and writes "key=value" pairs in env
+//
+// It can be used with the Env field of an exec.Cmd:
+//
+//	err := dotenv.Load(dotenv.Env(dotenv.SliceEnvironment(&cmd.Env)))
+func SliceEnvironment(env *[]string) Environment {
+	return sliceEnvironment{env: env}
+}
+
+type osEnvironment struct{}
+
+func (osEnvironment) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (osEnvironment) Set(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+func (osEnvironment) Unset(key string) error {
+	return os.Unsetenv(key)
+}
+
+func (osEnvironment) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, line := range environ {
+		keys = append(keys, strings.SplitN(line, "=", 2)[0])
+	}
+
+	return keys
+}
+
+type mapEnvironment map[string]string
+
+func (e mapEnvironment) Lookup(key string) (string, bool) {
+	value, exists := e[key]
+
+	return value, exists
+}
+
+func (e mapEnvironment) Set(key, value string) error {
+	e[key] = value
+
+	return nil
+}
+
+func (e mapEnvironment) Unset(key string) error {
+	delete(e, key)
+
+	return nil
+}
+
+func (e mapEnvironment) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+type sliceEnvironment struct {
+	env *[]string
+}
+
+// index returns the position of the last pair for key, or -1; the last pair wins just as it does for exec.Cmd
+func (e sliceEnvironment) index(key string) int {
+	for i := len(*e.env) - 1; i >= 0; i-- {
+		if strings.HasPrefix((*e.env)[i], key+"=") {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (e sliceEnvironment) Lookup(key string) (string, bool) {
+	if i := e.index(key); i != -1 {
+		return (*e.env)[i][len(key)+1:], true
+	}
+
+	return "", false
+}
+
+func (e sliceEnvironment) Set(key, value string) error {
+	if i := e.index(key); i != -1 {
+		(*e.env)[i] = key + "=" + value
+		return nil
+	}
+	*e.env = append(*e.env, key+"="+value)
+
+	return nil
+}
+
+func (e sliceEnvironment) Unset(key string) error {
+	env := (*e.env)[:0]
+	for _, pair := range *e.env {
+		if !strings.HasPrefix(pair, key+"=") {
+			env = append(env, pair)
+		}
+	}
+	*e.env = env
+
+	return nil
+}
+
+func (e sliceEnvironment) Keys() []string {
+	keys := make([]string, 0, len(*e.env))
+	seen := make(map[string]bool, len(*e.env))
+	for _, pair := range *e.env {
+		key := strings.SplitN(pair, "=", 2)[0]
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// environmentEnvs copies the variables in env into a map
+func environmentEnvs(env Environment) envVars {
+	keys := env.Keys()
+	envs := make(envVars, len(keys))
+
+	for _, key := range keys {
+		if value, exists := env.Lookup(key); exists {
+			envs[key] = value
+		}
+	}
+
+	return envs
+}
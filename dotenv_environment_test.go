@@ -0,0 +1,168 @@
+package dotenv
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestEnvironments(t *testing.T) {
+	tests := map[string]struct {
+		env  func() (Environment, func() envVars)
+		want envVars
+	}{
+		"map": {
+			env: func() (Environment, func() envVars) {
+				m := map[string]string{"FOO": "foo", "BAR": "bar"}
+				return MapEnvironment(m), func() envVars { return m }
+			},
+			want: envVars{"FOO": "changed", "BAZ": "baz"},
+		},
+		"slice": {
+			env: func() (Environment, func() envVars) {
+				env := []string{"FOO=foo", "BAR=bar", "FOO=last"}
+				return SliceEnvironment(&env), func() envVars {
+					envs := envVars{}
+					for _, pair := range env {
+						kv := strings.SplitN(pair, "=", 2)
+						envs[kv[0]] = kv[1]
+					}
+					return envs
+				}
+			},
+			want: envVars{"FOO": "changed", "BAZ": "baz"},
+		},
+		"os": {
+			env: func() (Environment, func() envVars) {
+				os.Clearenv()
+				t.Setenv("FOO", "foo")
+				t.Setenv("BAR", "bar")
+				return OSEnvironment(), systemEnvs
+			},
+			want: envVars{"FOO": "changed", "BAZ": "baz"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env, contents := tt.env()
+
+			keys := env.Keys()
+			sort.Strings(keys)
+			if want := []string{"BAR", "FOO"}; !reflect.DeepEqual(keys, want) {
+				t.Errorf("Keys() = %v, want %v", keys, want)
+			}
+			if _, exists := env.Lookup("BAZ"); exists {
+				t.Errorf("Lookup() found an unset key")
+			}
+
+			for _, err := range []error{env.Set("FOO", "changed"), env.Set("BAZ", "baz"), env.Unset("BAR")} {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if value, exists := env.Lookup("FOO"); !exists || value != "changed" {
+				t.Errorf("Lookup() = %q, %v, want %q, true", value, exists, "changed")
+			}
+			if got := contents(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(dir string) {
+		err := os.Chdir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}(pwd)
+
+	os.Clearenv()
+	t.Setenv("DOTENV", "process")
+
+	t.Run("Load does not overwrite the target", func(t *testing.T) {
+		m := map[string]string{"OPTION_A": "predefined"}
+		err := Load(Env(MapEnvironment(m)), Files(".env", "plain.env"), RequiredKeys("OPTION_A", "DOTENV"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		want := map[string]string{
+			"PLAIN":    "true",
+			"OPTION_A": "predefined",
+			"OPTION_B": "2",
+			"OPTION_C": "3",
+			"OPTION_D": "4",
+			"OPTION_E": "5",
+			"DOTENV":   "true",
+		}
+		if !reflect.DeepEqual(m, want) {
+			t.Errorf("target = %v, want %v", m, want)
+		}
+	})
+
+	t.Run("Load overloads the target", func(t *testing.T) {
+		var env []string
+		err := Load(Env(SliceEnvironment(&env)), Files(".env"), Overload())
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if want := []string{"DOTENV=true"}; !reflect.DeepEqual(env, want) {
+			t.Errorf("target = %v, want %v", env, want)
+		}
+	})
+
+	t.Run("Load checks required keys in the target", func(t *testing.T) {
+		err := Load(Env(MapEnvironment(map[string]string{})), Files(".env"), RequiredKeys("PLAIN"))
+		if err == nil {
+			t.Errorf("Load() error = nil, want an error")
+		}
+	})
+
+	t.Run("LoadWithRestore restores the target", func(t *testing.T) {
+		m := map[string]string{"DOTENV": "before"}
+		restore, err := LoadWithRestore(Env(MapEnvironment(m)), Files(".env", "plain.env"), Overload())
+		if err != nil {
+			t.Fatalf("LoadWithRestore() error = %v", err)
+		}
+		if err := restore(); err != nil {
+			t.Fatalf("restore() error = %v", err)
+		}
+		if want := map[string]string{"DOTENV": "before"}; !reflect.DeepEqual(m, want) {
+			t.Errorf("target = %v, want %v", m, want)
+		}
+	})
+
+	t.Run("Parse uses the target", func(t *testing.T) {
+		got, err := Parse(Env(MapEnvironment(map[string]string{"OPTION_A": "predefined"})), Files(".env", "plain.env"))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		want := map[string]string{
+			"PLAIN":    "true",
+			"OPTION_A": "predefined",
+			"OPTION_B": "2",
+			"OPTION_C": "3",
+			"OPTION_D": "4",
+			"OPTION_E": "5",
+			"DOTENV":   "true",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() got = %v, want %v", got, want)
+		}
+	})
+
+	if envs := systemEnvs(); !reflect.DeepEqual(envs, envVars{"DOTENV": "process"}) {
+		t.Errorf("ENV = %v, want it to be unchanged", envs)
+	}
+}
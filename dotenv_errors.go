@@ -0,0 +1,148 @@
+package dotenv
+
+import (
+	"fmt"
+)
+
+// ParseError is returned when the contents of an environment variables file cannot be parsed
+//
+// Use errors.As to retrieve the location and the reason for the failure.
+type ParseError struct {
+	// File is the path of the file being parsed; it is empty when no file was involved
+	File string
+	// Line is the 1-based line number of the offending text
+	Line int
+	// Column is the 1-based column of the offending text
+	Column int
+	// Text is the offending text
+	Text string
+	// Reason describes what is wrong with the text
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %s: %q", linePosition(e.File, e.Line), e.Column, e.Reason, e.Text)
+}
+
+// linePosition returns "file:line", or only the line when no file was involved
+func linePosition(file string, line int) string {
+	if file == "" {
+		return fmt.Sprintf("%d", line)
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// newParseError builds a ParseError for the text found at offset within contents
+func newParseError(fileName, contents string, offset int, text, reason string) *ParseError {
+	line, column := position(contents, offset)
+
+	return &ParseError{
+		File:   fileName,
+		Line:   line,
+		Column: column,
+		Text:   text,
+		Reason: reason,
+	}
+}
+
+// position converts a byte offset within contents into a 1-based line and column
+func position(contents string, offset int) (int, int) {
+	line, lineStart := 1, 0
+	for i := 0; i < offset && i < len(contents); i++ {
+		if contents[i] == '\n' || contents[i] == '\r' && (i+1 == len(contents) || contents[i+1] != '\n') {
+			line++
+			lineStart = i + 1
+		}
+	}
+
+	return line, offset - lineStart + 1
+}
+
+// RequiredVariableError is returned when a `${VAR:?message}` or `${VAR?message}` expansion finds VAR unset
+//
+// The `:?` form also treats an empty VAR as unset.
+type RequiredVariableError struct {
+	// File is the path of the file being parsed; it is empty when no file was involved
+	File string
+	// Line is the 1-based line number of the assignment containing the expansion
+	Line int
+	// Key is the key being assigned
+	Key string
+	// Variable is the name of the unset variable
+	Variable string
+	// Message is the message provided with the expansion
+	Message string
+}
+
+func (e *RequiredVariableError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = "parameter null or not set"
+	}
+
+	return fmt.Sprintf("%s: %s: %s: %s", linePosition(e.File, e.Line), e.Key, e.Variable, message)
+}
+
+// CommandError is returned when a `$(...)` command substitution fails
+type CommandError struct {
+	// File is the path of the file being parsed; it is empty when no file was involved
+	File string
+	// Line is the 1-based line number of the assignment containing the command
+	Line int
+	// Key is the key being assigned
+	Key string
+	// Command is the command that was run
+	Command string
+	// Err is the error returned by the CommandRunner
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s: command %q failed: %s", linePosition(e.File, e.Line), e.Key, e.Command, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// DecryptionError is returned when an encrypted value cannot be decrypted
+type DecryptionError struct {
+	// File is the path of the file being parsed; it is empty when no file was involved
+	File string
+	// Line is the 1-based line number of the assignment containing the value
+	Line int
+	// Key is the key being assigned
+	Key string
+	Err error
+}
+
+func (e *DecryptionError) Error() string {
+	return fmt.Sprintf("%s: %s: unable to decrypt value: %s", linePosition(e.File, e.Line), e.Key, e.Err)
+}
+
+func (e *DecryptionError) Unwrap() error {
+	return e.Err
+}
+
+// SecretError is returned when a SecretProvider is unable to resolve a value
+type SecretError struct {
+	// File is the path of the file being parsed; it is empty when no file was involved
+	File string
+	// Line is the 1-based line number of the assignment containing the reference
+	Line int
+	// Key is the key being assigned
+	Key string
+	// Reference is the value that was being resolved
+	Reference string
+	// Err is the error returned by the SecretProvider
+	Err error
+}
+
+func (e *SecretError) Error() string {
+	return fmt.Sprintf("%s: %s: unable to resolve %q: %s", linePosition(e.File, e.Line), e.Key, e.Reference, e.Err)
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,253 @@
+package dotenv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Format returns the contents of an environment variables file in canonical form
+//
+// Every assignment is written as `KEY=value`, keeping any `export` prefix. Values are left unquoted
+// when they can be and double quoted otherwise; values that span lines or contain backslashes, and
+// single quoted values that would need escaping, are kept as written. Comments are kept, indentation
+// and trailing whitespace are removed, and runs of blank lines become a single blank line. The line
+// ending of the first line is used throughout.
+//
+// When sortKeys is true the statements within each group of lines separated by blank lines are sorted
+// by key, and the comment lines directly above a key move with it.
+//
+// The formatted contents are parsed again and an error is returned, rather than the formatted contents,
+// when any value would be different, such as when sorting would move a key below one that refers to it.
+func Format(src []byte, sortKeys bool) ([]byte, error) {
+	contents := string(src)
+	statements, err := lex("", contents)
+	if err != nil {
+		return nil, err
+	}
+
+	// paragraphs holds the formatted lines of each group of statements between blank lines
+	paragraphs := make([][]formatUnit, 0)
+	var current []formatUnit
+	var comments []string
+	for _, st := range statements {
+		switch st.kind {
+		case blankStatement:
+			if len(comments) > 0 {
+				current = append(current, formatUnit{lines: comments})
+				comments = nil
+			}
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, current)
+				current = nil
+			}
+		case commentStatement:
+			comments = append(comments, trimTrailingSpace(contents[st.commentStart:st.end]))
+		default:
+			current = append(current, formatUnit{key: st.key, lines: append(comments, formatStatement(contents, st))})
+			comments = nil
+		}
+	}
+	if len(comments) > 0 {
+		current = append(current, formatUnit{lines: comments})
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+
+	newline := firstLineEnding(contents)
+	var b strings.Builder
+	for i, paragraph := range paragraphs {
+		if sortKeys {
+			sortUnits(paragraph)
+		}
+		if i > 0 {
+			b.WriteString(newline)
+		}
+		for _, unit := range paragraph {
+			for _, line := range unit.lines {
+				b.WriteString(line)
+				b.WriteString(newline)
+			}
+		}
+	}
+	formatted := b.String()
+
+	before, err := formatResult(contents)
+	if err != nil {
+		return nil, err
+	}
+	after, err := formatResult(formatted)
+	if err != nil {
+		return nil, err
+	}
+	if key := before.changedKey(after); key != "" {
+		if sortKeys {
+			return nil, fmt.Errorf("sorting the keys would change the value of %s", key)
+		}
+		return nil, fmt.Errorf("formatting would change the value of %s", key)
+	}
+
+	return []byte(formatted), nil
+}
+
+// formatUnit is a statement along with the comment lines directly above it
+//
+// The key is empty for comment lines that are not followed by a statement.
+type formatUnit struct {
+	key   string
+	lines []string
+}
+
+// sortUnits sorts the statements by key while the comment lines that end a group stay at the end
+func sortUnits(units []formatUnit) {
+	n := len(units)
+	if n > 0 && units[n-1].key == "" {
+		n--
+	}
+	sort.SliceStable(units[:n], func(i, j int) bool {
+		return units[i].key < units[j].key
+	})
+}
+
+// formatStatement returns the canonical form of an assignment or an `export KEY` statement
+func formatStatement(contents string, st statement) string {
+	text := st.key
+	if st.exported {
+		text = "export " + text
+	}
+	if st.kind == assignStatement {
+		text += "=" + formatValue(contents[st.valueStart:st.valueEnd], st.quote)
+	}
+	if st.commentStart != -1 {
+		text += " " + trimTrailingSpace(contents[st.commentStart:st.end])
+	}
+
+	return text
+}
+
+// formatValue returns the canonical form of a raw value, which is always read back as the same value
+func formatValue(raw string, quote byte) string {
+	value := raw
+	if quote != 0 {
+		value = raw[1 : len(raw)-1]
+	}
+
+	switch {
+	case strings.ContainsAny(value, "\\\r\n"):
+		// escapes and line breaks are kept as written
+		return raw
+	case quote == '\'' && strings.ContainsAny(value, `"$`):
+		// single quotes avoid escaping these
+		return raw
+	case quote == '\'' || !strings.Contains(value, "$"):
+		return quoteValue(value)
+	case !strings.ContainsAny(value, "\"'# \t\f"):
+		// variables are expanded the same way whether or not the value is double quoted
+		return value
+	case !strings.Contains(value, `"`):
+		return `"` + value + `"`
+	}
+
+	return raw
+}
+
+// firstLineEnding returns the first line ending found in contents, or "\n" when there is none
+func firstLineEnding(contents string) string {
+	i := strings.IndexAny(contents, "\r\n")
+	switch {
+	case i == -1:
+		return "\n"
+	case strings.HasPrefix(contents[i:], "\r\n"):
+		return "\r\n"
+	}
+
+	return contents[i : i+1]
+}
+
+func trimTrailingSpace(s string) string {
+	return strings.TrimRight(s, " \t\f")
+}
+
+// formatResults are the values that contents parse into along with the keys marked as secrets
+type formatResults struct {
+	values  envVars
+	secrets map[string]bool
+}
+
+// formatResult parses contents in a way that does not depend on the environment or any other files
+//
+// Each value is expanded twice: once as though every variable were set in the environment, which keeps
+// the references and commands as they were written, and once using only what the file has set so far,
+// which shows whether the statements that depend on each other are still in order.
+func formatResult(contents string) (formatResults, error) {
+	statements, err := lex("", contents)
+	if err != nil {
+		return formatResults{}, err
+	}
+
+	results := formatResults{values: make(envVars), secrets: make(map[string]bool)}
+	parsedEnvs := make(envVars)
+	written := func(key string) (string, bool) {
+		return "${" + key + "}", true
+	}
+	parsed := func(key string) (string, bool) {
+		value, exists := parsedEnvs[key]
+		return value, exists
+	}
+	command := func(cmd string) (string, error) {
+		return "$(" + cmd + ")", nil
+	}
+
+	secret := false
+	for _, st := range statements {
+		switch st.kind {
+		case commentStatement:
+			secret = secret || isSecretComment(contents, st)
+			continue
+		case assignStatement:
+		default:
+			secret = false
+			continue
+		}
+
+		raw := contents[st.valueStart:st.valueEnd]
+		asWritten, err := decodeValue(raw, st.quote, written, command)
+		if err != nil {
+			return formatResults{}, err
+		}
+		value, err := decodeValue(raw, st.quote, parsed, command)
+		if err != nil {
+			// a `${VAR:?message}` reference to a variable that the file does not set
+			value = err.Error()
+		}
+		parsedEnvs[st.key] = value
+		results.values[st.key] = asWritten + "\x00" + value
+		if secret || st.commentStart != -1 && isSecretComment(contents, st) {
+			results.secrets[st.key] = true
+		}
+		secret = false
+	}
+
+	return results, nil
+}
+
+// changedKey returns a key that is parsed differently in other, or an empty string when nothing changed
+func (r formatResults) changedKey(other formatResults) string {
+	keys := make([]string, 0, len(r.values)+len(other.values))
+	for key := range r.values {
+		keys = append(keys, key)
+	}
+	for key := range other.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, exists := other.values[key]
+		if value != r.values[key] || !exists || r.secrets[key] != other.secrets[key] {
+			return key
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,110 @@
+package dotenv
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	type args struct {
+		src      string
+		sortKeys bool
+	}
+	tests := map[string]struct {
+		args    args
+		want    string
+		wantErr bool
+	}{
+		"separators and spacing": {
+			args:    args{src: "  A = one\nB: two\nexport   C=three\n"},
+			want:    "A=one\nB=two\nexport C=three\n",
+			wantErr: false,
+		},
+		"quoting": {
+			args:    args{src: "A='plain'\nB=\"plain\"\nC=hello world\nD='say \"hi\"'\nE='$LITERAL'\nF=\"#fff\"\nG=\nH=\"\"\n"},
+			want:    "A=plain\nB=plain\nC=\"hello world\"\nD='say \"hi\"'\nE='$LITERAL'\nF=\"#fff\"\nG=\nH=\n",
+			wantErr: false,
+		},
+		"variables": {
+			args:    args{src: "A=\"${HOST}\"\nB=$HOST and more\nC=\"say \\\"$HOST\\\"\"\nD=$(date +%s)\n"},
+			want:    "A=${HOST}\nB=\"$HOST and more\"\nC=\"say \\\"$HOST\\\"\"\nD=\"$(date +%s)\"\n",
+			wantErr: false,
+		},
+		"multi-line values": {
+			args:    args{src: "KEY=\"-----BEGIN-----\nabc\n-----END-----\"\n"},
+			want:    "KEY=\"-----BEGIN-----\nabc\n-----END-----\"\n",
+			wantErr: false,
+		},
+		"comments": {
+			args:    args{src: "\n\n  # heading  \nA=1   #   inline  \nB= # empty\nexport A # exported\n\n\n\n# trailing\n\n"},
+			want:    "# heading\nA=1 #   inline\nB= # empty\nexport A # exported\n\n# trailing\n",
+			wantErr: false,
+		},
+		"line endings": {
+			args:    args{src: "\ufeffA = 1\r\nB = 2"},
+			want:    "A=1\r\nB=2\r\n",
+			wantErr: false,
+		},
+		"sorted keys": {
+			args:    args{src: "# section\nC=3\n# about A\nA=1\nB=2\n# end\n\nZ=26\nY=25\n", sortKeys: true},
+			want:    "# about A\nA=1\nB=2\n# section\nC=3\n# end\n\nY=25\nZ=26\n",
+			wantErr: false,
+		},
+		"sorted duplicate keys keep their order": {
+			args:    args{src: "B=1\nA=first\nA=second\n", sortKeys: true},
+			want:    "A=first\nA=second\nB=1\n",
+			wantErr: false,
+		},
+		"sorting that changes a value": {
+			args:    args{src: "B=1\nA=$B\n", sortKeys: true},
+			want:    "",
+			wantErr: true,
+		},
+		"sorting that moves a secret comment": {
+			args:    args{src: "# @secret\nB=1\nA=2\n", sortKeys: true},
+			want:    "A=2\n# @secret\nB=1\n",
+			wantErr: false,
+		},
+		"invalid": {
+			args:    args{src: "A=\"unterminated\n"},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Format([]byte(tt.args.src), tt.args.sortKeys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Format() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("Format() got = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			again, err := Format(got, tt.args.sortKeys)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("Format() is not stable: got = %q, error = %v", again, err)
+			}
+
+			before, err := ParseString(tt.args.src, Env(MapEnvironment(map[string]string{"HOST": "localhost"})))
+			if err != nil {
+				t.Fatalf("ParseString() error = %v", err)
+			}
+			after, err := ParseString(string(got), Env(MapEnvironment(map[string]string{"HOST": "localhost"})))
+			if err != nil {
+				t.Fatalf("ParseString() error = %v", err)
+			}
+			for key, value := range before {
+				if after[key] != value {
+					t.Errorf("Format() changed %s from %q to %q", key, value, after[key])
+				}
+			}
+			if len(before) != len(after) {
+				t.Errorf("Format() changed the keys from %v to %v", before, after)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The rules checked by Lint
+const (
+	// RuleSyntax reports a file that cannot be parsed
+	RuleSyntax = "syntax"
+	// RuleDuplicateKey reports a key that is defined more than once in the same file
+	RuleDuplicateKey = "duplicate-key"
+	// RuleKeyCase reports a key that differs from another key only in case
+	RuleKeyCase = "key-case"
+	// RuleTrailingWhitespace reports whitespace after an unquoted value, which is dropped; quoting keeps it
+	RuleTrailingWhitespace = "trailing-whitespace"
+	// RuleInlineComment reports an unquoted value that is cut short by what looks like part of the value
+	RuleInlineComment = "inline-comment"
+	// RuleUndefinedVariable reports a reference to a variable that is not set and expands to an empty string
+	RuleUndefinedVariable = "undefined-variable"
+	// RuleUndefinedExport reports an `export KEY` line for a key that the file does not define
+	RuleUndefinedExport = "undefined-export"
+	// RuleCredential reports a value that looks like a credential, such as an AWS key or a private key
+	RuleCredential = "credential"
+)
+
+// Issue is a problem found by Lint
+type Issue struct {
+	File string `json:"file"`
+	// Line and Column are 1-based
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Key    string `json:"key,omitempty"`
+	// Rule is one of the Rule constants
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", i.File, i.Line, i.Column, i.Message, i.Rule)
+}
+
+var (
+	awsAccessKeyRe = regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)
+	privateKeyRe   = regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY-----`)
+)
+
+// lintFile is a file that was read and lexed by Lint
+type lintFile struct {
+	name       string
+	contents   string
+	statements []statement
+	// keys holds the line each key is first defined on
+	keys map[string]int
+}
+
+// Lint checks the files for mistakes and likely leaked credentials without parsing the values
+//
+// The files are chosen with the same options as Parse(). Files that cannot be lexed are reported with
+// the RuleSyntax rule; an error is only returned when the files cannot be read. The issues are sorted
+// by file, in the order the files are read, and then by position.
+func Lint(options ...ParseOption) ([]Issue, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.parseOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fileNames, err := buildFileList(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0)
+	files := make([]*lintFile, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		if info, err := cfg.stat(fileName); err != nil || info.IsDir() {
+			if errors.Is(err, fs.ErrNotExist) && cfg.requireFiles {
+				return nil, fmt.Errorf("environment variables file was not found: %s", fileName)
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			continue
+		}
+
+		contents, err := cfg.readFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		f := &lintFile{name: fileName, contents: string(contents), keys: make(map[string]int)}
+		f.statements, err = lex(fileName, f.contents)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			issues = append(issues, Issue{
+				File:    fileName,
+				Line:    parseErr.Line,
+				Column:  parseErr.Column,
+				Rule:    RuleSyntax,
+				Message: fmt.Sprintf("%s: %q", parseErr.Reason, parseErr.Text),
+			})
+			continue
+		}
+		for _, st := range f.statements {
+			if _, exists := f.keys[st.key]; st.kind == assignStatement && !exists {
+				f.keys[st.key] = st.line
+			}
+		}
+		files = append(files, f)
+	}
+
+	currentEnv := cfg.currentEnvs()
+	// caseKeys holds the first spelling of each key, by its upper case spelling, along with where it was found
+	caseKeys := make(map[string]Issue)
+	for _, f := range files {
+		fileIssues := f.lint(currentEnv, caseKeys)
+		sort.SliceStable(fileIssues, func(i, j int) bool {
+			if fileIssues[i].Line != fileIssues[j].Line {
+				return fileIssues[i].Line < fileIssues[j].Line
+			}
+			return fileIssues[i].Column < fileIssues[j].Column
+		})
+		issues = append(issues, fileIssues...)
+	}
+
+	return issues, nil
+}
+
+func (f *lintFile) lint(currentEnv envVars, caseKeys map[string]Issue) []Issue {
+	issues := make([]Issue, 0)
+	add := func(offset int, key, rule, message string) {
+		line, column := position(f.contents, offset)
+		issues = append(issues, Issue{File: f.name, Line: line, Column: column, Key: key, Rule: rule, Message: message})
+	}
+
+	// defined is true for the keys that a reference can be expanded with at the current statement; just as
+	// with Parse(), only the environment and the statements above it in the same file are used
+	defined := make(map[string]bool)
+
+	for _, st := range f.statements {
+		switch st.kind {
+		case exportStatement:
+			if _, exists := f.keys[st.key]; !exists {
+				add(st.keyStart, st.key, RuleUndefinedExport, fmt.Sprintf("%s is exported but the file does not define it", st.key))
+			}
+			continue
+		case assignStatement:
+		default:
+			continue
+		}
+
+		if line := f.keys[st.key]; line != st.line {
+			add(st.keyStart, st.key, RuleDuplicateKey, fmt.Sprintf("%s is already defined on line %d; the last definition is used", st.key, line))
+		}
+
+		upperKey := strings.ToUpper(st.key)
+		if first, exists := caseKeys[upperKey]; !exists {
+			caseKeys[upperKey] = Issue{File: f.name, Line: st.line, Key: st.key}
+		} else if first.Key != st.key {
+			where := fmt.Sprintf("line %d", first.Line)
+			if first.File != f.name {
+				where = fmt.Sprintf("%s:%d", filepath.Base(first.File), first.Line)
+			}
+			add(st.keyStart, st.key, RuleKeyCase, fmt.Sprintf("%s differs only in case from %s defined on %s", st.key, first.Key, where))
+		}
+
+		raw := f.contents[st.valueStart:st.valueEnd]
+		text := f.contents[st.start:st.end]
+		if trimmed := strings.TrimRight(text, " \t\f"); st.quote == 0 && st.commentStart == -1 && len(trimmed) != len(text) {
+			add(st.start+len(trimmed), st.key, RuleTrailingWhitespace, "trailing whitespace after the value is not part of it")
+		}
+
+		if st.quote == 0 && st.commentStart != -1 && st.commentStart+1 < st.end && !isSpace(f.contents[st.commentStart+1]) {
+			comment := f.contents[st.commentStart:st.end]
+			add(st.commentStart, st.key, RuleInlineComment, fmt.Sprintf("the value ends before %q, which is read as a comment; quote the value if it is part of it", comment))
+		}
+
+		for _, ref := range variableReferences(raw, st.quote) {
+			if _, exists := currentEnv[ref.name]; exists || defined[ref.name] {
+				continue
+			}
+			message := fmt.Sprintf("$%s is not set and expands to an empty string", ref.name)
+			if line, exists := f.keys[ref.name]; exists && line >= st.line {
+				message = fmt.Sprintf("$%s is used before it is defined on line %d and expands to an empty string", ref.name, line)
+			}
+			add(st.valueStart+ref.offset, st.key, RuleUndefinedVariable, message)
+		}
+		defined[st.key] = true
+
+		if value, err := decodeValue(raw, st.quote, nil, nil); err == nil {
+			if message := credentialMessage(st.key, value); message != "" {
+				add(st.valueStart, st.key, RuleCredential, message)
+			}
+		}
+	}
+
+	return issues
+}
+
+// variableReference is a `$NAME` or `${NAME}` reference found at offset within a raw value
+type variableReference struct {
+	name   string
+	offset int
+}
+
+// variableReferences returns the plain references within a raw value
+//
+// References that provide a default, an alternative or an error message, such as `${NAME:-default}`,
+// are skipped because they do not expand to an empty string by accident.
+func variableReferences(raw string, quote byte) []variableReference {
+	if quote == '\'' {
+		return nil
+	}
+
+	refs := make([]variableReference, 0)
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\':
+			i++
+		case raw[i] != '$' || i+1 == len(raw):
+		case raw[i+1] == '(':
+			if end := closingParen(raw[i+1:]); end != -1 {
+				i += end + 1
+			}
+		case raw[i+1] == '{':
+			end := closingBrace(raw[i+1:])
+			if end == -1 {
+				continue
+			}
+			if name, op, _ := splitParameter(raw[i+2 : i+1+end]); op == "" && isName(name) {
+				refs = append(refs, variableReference{name: name, offset: i})
+			}
+			i += end + 1
+		default:
+			n := i + 1
+			for n < len(raw) && isNameChar(raw[n]) {
+				n++
+			}
+			if n > i+1 {
+				refs = append(refs, variableReference{name: raw[i+1 : n], offset: i})
+			}
+			i = n - 1
+		}
+	}
+
+	return refs
+}
+
+// credentialMessage describes why value looks like a credential, or returns an empty string
+func credentialMessage(key, value string) string {
+	if key == PublicKeyName || IsEncrypted(value) {
+		return ""
+	}
+
+	switch {
+	case awsAccessKeyRe.MatchString(value):
+		return "the value looks like an AWS access key ID"
+	case privateKeyRe.MatchString(value):
+		return "the value contains a private key"
+	}
+
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if password, exists := u.User.Password(); exists && password != "" && !strings.Contains(password, "$") {
+				return "the value is a URL with a password"
+			}
+		}
+		return ""
+	}
+
+	if isHighEntropy(value) {
+		return "the value looks like a secret; it is long and random"
+	}
+
+	return ""
+}
+
+// isHighEntropy reports whether value looks like a randomly generated token or key
+func isHighEntropy(value string) bool {
+	if len(value) < 20 || strings.ContainsAny(value, " \t\n$") {
+		return false
+	}
+
+	hasLetter, hasDigit, isHex := false, false, true
+	for _, r := range value {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+		isHex = isHex && strings.ContainsRune("0123456789abcdefABCDEF", r)
+	}
+	if !hasLetter || !hasDigit {
+		return false
+	}
+
+	entropy := shannonEntropy(value)
+	if isHex {
+		return len(value) >= 32 && entropy >= 3
+	}
+
+	return entropy >= 4
+}
+
+// shannonEntropy returns the average number of bits of information in each character of s
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
@@ -1,5 +1,11 @@
 package dotenv
 
+import (
+	"io/fs"
+	"strings"
+	"time"
+)
+
 type LoadOption interface {
 	loadOption(c *envCfg) error
 }
@@ -74,6 +80,9 @@ func (o PathsOpt) parseOption(c *envCfg) error {
 type OverloadOpt bool
 
 // Overload option to replace any ENV values with the values read from files
+//
+// When parsing, the values read from files take priority over the environment, and the values read from
+// later files take priority over earlier ones.
 func Overload() OverloadOpt {
 	return true
 }
@@ -84,9 +93,17 @@ func (o OverloadOpt) loadOption(c *envCfg) error {
 	return nil
 }
 
+func (o OverloadOpt) parseOption(c *envCfg) error {
+	c.overload = bool(o)
+
+	return nil
+}
+
 type RequiredKeysOpt []string
 
-// RequiredKeys option will perform a check for any missing keys after loading the files
+// RequiredKeys option will perform a check for any missing keys after loading or parsing the files
+//
+// When parsing, a key is set when it is either in the environment or in the parsed values.
 func RequiredKeys(keys ...string) RequiredKeysOpt {
 	return keys
 }
@@ -97,6 +114,12 @@ func (o RequiredKeysOpt) loadOption(c *envCfg) error {
 	return nil
 }
 
+func (o RequiredKeysOpt) parseOption(c *envCfg) error {
+	c.requiredKeys = o
+
+	return nil
+}
+
 type AllFilesRequiredOpt bool
 
 // AllFilesRequired option is used to raise an error if any files are missing
@@ -115,3 +138,261 @@ func (AllFilesRequiredOpt) parseOption(c *envCfg) error {
 
 	return nil
 }
+
+type FSOpt struct {
+	fsys fs.FS
+}
+
+// FS option to read files from fsys, such as an embed.FS, instead of the operating system filesystem
+//
+// Paths are slash separated and relative to the root of fsys.
+func FS(fsys fs.FS) FSOpt {
+	return FSOpt{fsys: fsys}
+}
+
+func (o FSOpt) loadOption(c *envCfg) error {
+	c.fsys = o.fsys
+
+	return nil
+}
+
+func (o FSOpt) parseOption(c *envCfg) error {
+	c.fsys = o.fsys
+
+	return nil
+}
+
+type AllowCommandSubstitutionOpt bool
+
+// AllowCommandSubstitution option to replace `$(command)` in unquoted and double quoted values with the output of the command
+//
+// Commands are run from the directory of the file they were read from. Command substitution is disabled by default.
+func AllowCommandSubstitution() AllowCommandSubstitutionOpt {
+	return true
+}
+
+func (o AllowCommandSubstitutionOpt) loadOption(c *envCfg) error {
+	c.commands = bool(o)
+
+	return nil
+}
+
+func (o AllowCommandSubstitutionOpt) parseOption(c *envCfg) error {
+	c.commands = bool(o)
+
+	return nil
+}
+
+type CommandRunnerOpt struct {
+	runner CommandRunner
+}
+
+// CommandSubstitutionRunner option to set the CommandRunner used to run `$(command)` substitutions
+//
+// The system shell is used by default. This option does not enable command substitution by itself.
+func CommandSubstitutionRunner(runner CommandRunner) CommandRunnerOpt {
+	return CommandRunnerOpt{runner: runner}
+}
+
+func (o CommandRunnerOpt) loadOption(c *envCfg) error {
+	c.commandRunner = o.runner
+
+	return nil
+}
+
+func (o CommandRunnerOpt) parseOption(c *envCfg) error {
+	c.commandRunner = o.runner
+
+	return nil
+}
+
+type CommandTimeoutOpt time.Duration
+
+// CommandSubstitutionTimeout option to set how long each `$(command)` substitution may run; the default is 10 seconds
+func CommandSubstitutionTimeout(timeout time.Duration) CommandTimeoutOpt {
+	return CommandTimeoutOpt(timeout)
+}
+
+func (o CommandTimeoutOpt) loadOption(c *envCfg) error {
+	c.commandTimeout = time.Duration(o)
+
+	return nil
+}
+
+func (o CommandTimeoutOpt) parseOption(c *envCfg) error {
+	c.commandTimeout = time.Duration(o)
+
+	return nil
+}
+
+type EnvOpt struct {
+	env Environment
+}
+
+// Env option to load values into, and check for existing values in, env instead of the process environment
+func Env(env Environment) EnvOpt {
+	if env == nil {
+		env = OSEnvironment()
+	}
+
+	return EnvOpt{env: env}
+}
+
+func (o EnvOpt) loadOption(c *envCfg) error {
+	c.env = o.env
+
+	return nil
+}
+
+func (o EnvOpt) parseOption(c *envCfg) error {
+	c.env = o.env
+
+	return nil
+}
+
+type SchemaOpt string
+
+// Schema option to validate the values against the rules found in a schema file, such as .env.schema or .env.example
+//
+// Keys that are not set receive the default from the schema. Every violation is reported by a single *SchemaError.
+func Schema(path string) SchemaOpt {
+	return SchemaOpt(path)
+}
+
+func (o SchemaOpt) loadOption(c *envCfg) error {
+	c.schema = string(o)
+
+	return nil
+}
+
+func (o SchemaOpt) parseOption(c *envCfg) error {
+	c.schema = string(o)
+
+	return nil
+}
+
+type SearchParentsOpt struct {
+	maxDepth int
+	markers  []string
+}
+
+// SearchParents option to look for the files in the parent directories of each path
+//
+// The search starts in each path and moves up one directory at a time until a directory containing
+// any of the files is found. It stops at the first directory containing a go.mod file or a .git
+// directory, which is treated as the root of the project. The files are read from the start path
+// when none are found.
+func SearchParents() SearchParentsOpt {
+	return SearchParentsOpt{markers: []string{"go.mod", ".git"}}
+}
+
+// SearchParentsUntil option works like SearchParents() but stops at a directory containing any of the
+// markers, or after moving up maxDepth directories; a maxDepth of 0 has no limit
+func SearchParentsUntil(maxDepth int, markers ...string) SearchParentsOpt {
+	return SearchParentsOpt{maxDepth: maxDepth, markers: markers}
+}
+
+func (o SearchParentsOpt) loadOption(c *envCfg) error {
+	c.searchParents = &o
+
+	return nil
+}
+
+func (o SearchParentsOpt) parseOption(c *envCfg) error {
+	c.searchParents = &o
+
+	return nil
+}
+
+type DecryptionKeyOpt string
+
+// DecryptionKey option to decrypt the values encrypted by EncryptValue with the hex encoded privateKey
+//
+// Without this option the key is read from the KeyFile() option or the DOTENV_PRIVATE_KEY environment
+// variable. Encrypted values are left as they are when no key is provided.
+func DecryptionKey(privateKey string) DecryptionKeyOpt {
+	return DecryptionKeyOpt(privateKey)
+}
+
+func (o DecryptionKeyOpt) loadOption(c *envCfg) error {
+	key, err := parsePrivateKey(string(o))
+	if err != nil {
+		return err
+	}
+	c.decryptionKey = key
+
+	return nil
+}
+
+func (o DecryptionKeyOpt) parseOption(c *envCfg) error {
+	key, err := parsePrivateKey(string(o))
+	if err != nil {
+		return err
+	}
+	c.decryptionKey = key
+
+	return nil
+}
+
+type KeyFileOpt string
+
+// KeyFile option to read the private key used to decrypt values from the DOTENV_PRIVATE_KEY key in a file, such as .env.keys
+func KeyFile(path string) KeyFileOpt {
+	return KeyFileOpt(path)
+}
+
+func (o KeyFileOpt) loadOption(c *envCfg) error {
+	c.keyFile = string(o)
+
+	return nil
+}
+
+func (o KeyFileOpt) parseOption(c *envCfg) error {
+	c.keyFile = string(o)
+
+	return nil
+}
+
+type ProvidersOpt []SecretProvider
+
+// Providers option to resolve values that are references to secrets using the providers for their URL schemes
+//
+// A value is a reference when it starts with the scheme of a provider followed by "://". References are
+// resolved after variables are expanded and values are decrypted. Failures are reported by a *SecretError.
+func Providers(providers ...SecretProvider) ProvidersOpt {
+	return providers
+}
+
+func (o ProvidersOpt) loadOption(c *envCfg) error {
+	c.providers = make(map[string]SecretProvider, len(o))
+	for _, provider := range o {
+		c.providers[strings.ToLower(provider.Scheme())] = provider
+	}
+
+	return nil
+}
+
+func (o ProvidersOpt) parseOption(c *envCfg) error {
+	c.providers = make(map[string]SecretProvider, len(o))
+	for _, provider := range o {
+		c.providers[strings.ToLower(provider.Scheme())] = provider
+	}
+
+	return nil
+}
+
+type SecretPatternsOpt []string
+
+// SecretPatterns option to set the patterns matching the keys that ParseVars() treats as secrets
+//
+// Patterns use the syntax of path.Match and ignore case; the defaults are *_SECRET, *_PASSWORD and *_TOKEN.
+// Keys marked with an `@secret` comment are always secrets.
+func SecretPatterns(patterns ...string) SecretPatternsOpt {
+	return append(SecretPatternsOpt{}, patterns...)
+}
+
+func (o SecretPatternsOpt) parseOption(c *envCfg) error {
+	c.secretPatterns = o
+
+	return nil
+}
@@ -0,0 +1,529 @@
+package dotenv
+
+import (
+	"strings"
+)
+
+const byteOrderMark = "\ufeff"
+
+type statementKind int
+
+const (
+	blankStatement statementKind = iota
+	commentStatement
+	assignStatement
+	exportStatement
+)
+
+// statement is a single line, or a multi-line quoted assignment, read by the lexer
+//
+// All offsets are byte offsets into the contents that were lexed.
+type statement struct {
+	kind statementKind
+	// line is the 1-based line the statement starts on
+	line int
+	// start and end span the statement, excluding the line ending
+	start, end int
+	// exported is true when the key was prefixed with `export`
+	exported         bool
+	key              string
+	keyStart, keyEnd int
+	// valueStart and valueEnd span the raw value, including any quotes
+	valueStart, valueEnd int
+	// quote is the quote character used for the value, or zero when unquoted
+	quote byte
+	// commentStart is the offset of the `#` of a trailing comment, or -1 when there is none
+	commentStart int
+}
+
+// lexer is a single pass state machine that splits the contents into statements
+type lexer struct {
+	fileName string
+	contents string
+	pos      int
+	line     int
+}
+
+// lex splits the contents of an environment variables file into statements
+func lex(fileName, contents string) ([]statement, error) {
+	l := &lexer{
+		fileName: fileName,
+		contents: contents,
+		line:     1,
+	}
+
+	// skip over a UTF-8 byte order mark
+	if strings.HasPrefix(contents, byteOrderMark) {
+		l.pos = len(byteOrderMark)
+	}
+
+	statements := make([]statement, 0, strings.Count(contents, "\n")+1)
+	for l.pos < len(l.contents) {
+		st, err := l.statement()
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, st)
+		l.newline()
+	}
+
+	return statements, nil
+}
+
+func (l *lexer) statement() (statement, error) {
+	st := statement{
+		line:         l.line,
+		start:        l.pos,
+		commentStart: -1,
+	}
+
+	l.skipSpace()
+	switch {
+	case l.atLineEnd():
+		st.kind = blankStatement
+		st.end = l.pos
+		return st, nil
+	case l.contents[l.pos] == '#':
+		st.kind = commentStatement
+		st.commentStart = l.pos
+		l.skipLine()
+		st.end = l.pos
+		return st, nil
+	}
+
+	st.keyStart = l.pos
+	st.key = l.word()
+	if st.key == "" {
+		return st, l.invalidLine(st)
+	}
+	st.keyEnd = l.pos
+
+	// `export KEY` is a prefix unless "export" is the key itself
+	if st.key == "export" && l.skipSpace() > 0 {
+		if start := l.pos; l.word() != "" {
+			st.exported = true
+			st.keyStart, st.keyEnd = start, l.pos
+			st.key = l.contents[start:l.pos]
+		}
+	}
+
+	spaces := l.skipSpace()
+	switch {
+	case l.peek() == '=':
+		l.pos++
+	case l.peek() == ':' && (l.pos+1 == len(l.contents) || isSpace(l.contents[l.pos+1]) || isLineEnd(l.contents[l.pos+1])):
+		l.pos++
+	case st.exported && (l.atLineEnd() || spaces > 0 && l.peek() == '#'):
+		st.kind = exportStatement
+		if !l.atLineEnd() {
+			st.commentStart = l.pos
+		}
+		l.skipLine()
+		st.end = l.pos
+		return st, nil
+	default:
+		return st, l.invalidLine(st)
+	}
+
+	st.kind = assignStatement
+	spaces = l.skipSpace()
+	st.valueStart = l.pos
+
+	switch c := l.peek(); {
+	case c == '\'' || c == '"':
+		if err := l.quoted(&st, c); err != nil {
+			return st, err
+		}
+	case c == '#' && spaces > 0:
+		// an empty value followed by a comment
+	default:
+		l.unquoted(&st)
+	}
+	st.valueEnd = l.pos
+
+	l.skipSpace()
+	if !l.atLineEnd() {
+		st.commentStart = l.pos
+		l.skipLine()
+	}
+	st.end = l.pos
+
+	return st, nil
+}
+
+// quoted scans a value wrapped in the quote character c
+//
+// A quote that is preceded by a backslash never closes a single quoted value. Double quoted values
+// treat a backslash as escaping the character that follows it. Older files rely on the single quoted
+// behavior for double quoted values too (`"say \\"hi\\""`), so that reading is used whenever the
+// escape aware one would leave text behind the closing quote.
+func (l *lexer) quoted(st *statement, c byte) error {
+	start := l.pos
+	end := -1
+	if c == '"' {
+		end = l.closingQuote(start, c, true)
+		if end != -1 && !l.onlyCommentFollows(end+1) {
+			end = -1
+		}
+	}
+	if end == -1 {
+		end = l.closingQuote(start, c, false)
+	}
+	if end == -1 {
+		text := l.contents[start:]
+		if i := strings.IndexAny(text, "\r\n"); i != -1 {
+			text = text[:i]
+		}
+		return newParseError(l.fileName, l.contents, start, text, "unterminated quoted value")
+	}
+
+	for i := start; i <= end; i++ {
+		if l.contents[i] == '\n' || l.contents[i] == '\r' && (i+1 == len(l.contents) || l.contents[i+1] != '\n') {
+			l.line++
+		}
+	}
+	l.pos = end + 1
+	st.quote = c
+
+	if !l.onlyCommentFollows(l.pos) {
+		l.skipSpace()
+		text := l.contents[l.pos:]
+		if i := strings.IndexAny(text, "\r\n"); i != -1 {
+			text = text[:i]
+		}
+		return newParseError(l.fileName, l.contents, l.pos, strings.TrimRight(text, " \t\f"), "unexpected text after quoted value")
+	}
+
+	return nil
+}
+
+// closingQuote returns the offset of the quote that closes the value opened at start, or -1
+func (l *lexer) closingQuote(start int, c byte, escapes bool) int {
+	for i := start + 1; i < len(l.contents); i++ {
+		switch l.contents[i] {
+		case '\\':
+			if escapes || i+1 < len(l.contents) && l.contents[i+1] == c {
+				i++
+			}
+		case c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// onlyCommentFollows reports whether the rest of the line starting at offset is blank or a comment
+func (l *lexer) onlyCommentFollows(offset int) bool {
+	for i := offset; i < len(l.contents); i++ {
+		switch c := l.contents[i]; {
+		case isSpace(c):
+		case c == '#' || isLineEnd(c):
+			return true
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// unquoted scans a value up to the end of the line or the start of an inline comment
+func (l *lexer) unquoted(st *statement) {
+	end := l.pos
+	for ; l.pos < len(l.contents) && !isLineEnd(l.contents[l.pos]); l.pos++ {
+		c := l.contents[l.pos]
+		if c == '#' && l.pos > st.valueStart && isSpace(l.contents[l.pos-1]) {
+			break
+		}
+		if !isSpace(c) {
+			end = l.pos + 1
+		}
+	}
+	l.pos = end
+}
+
+func (l *lexer) invalidLine(st statement) error {
+	l.skipLine()
+	text := strings.TrimSpace(l.contents[st.start:l.pos])
+
+	return newParseError(l.fileName, l.contents, st.start+strings.Index(l.contents[st.start:l.pos], text), text, "invalid line")
+}
+
+func (l *lexer) peek() byte {
+	if l.pos < len(l.contents) {
+		return l.contents[l.pos]
+	}
+
+	return 0
+}
+
+func (l *lexer) atLineEnd() bool {
+	return l.pos == len(l.contents) || isLineEnd(l.contents[l.pos])
+}
+
+func (l *lexer) skipSpace() int {
+	start := l.pos
+	for l.pos < len(l.contents) && isSpace(l.contents[l.pos]) {
+		l.pos++
+	}
+
+	return l.pos - start
+}
+
+func (l *lexer) skipLine() {
+	for l.pos < len(l.contents) && !isLineEnd(l.contents[l.pos]) {
+		l.pos++
+	}
+}
+
+// newline consumes a "\n", "\r\n" or lone "\r" line ending
+func (l *lexer) newline() {
+	switch {
+	case strings.HasPrefix(l.contents[l.pos:], "\r\n"):
+		l.pos += 2
+	case l.pos < len(l.contents):
+		l.pos++
+	default:
+		return
+	}
+	l.line++
+}
+
+func (l *lexer) word() string {
+	start := l.pos
+	for l.pos < len(l.contents) && isKeyChar(l.contents[l.pos]) {
+		l.pos++
+	}
+
+	return l.contents[start:l.pos]
+}
+
+// decodeValue removes the quotes and escapes from a raw value and expands any variables within it
+//
+// Variables are resolved with lookup; when lookup is nil variables are left as written. Command
+// substitutions are run with command; when command is nil they are left as written.
+func decodeValue(raw string, quote byte, lookup func(string) (string, bool), command func(string) (string, error)) (string, error) {
+	if quote != 0 {
+		raw = raw[1 : len(raw)-1]
+	}
+	if quote == '\'' {
+		return raw, nil
+	}
+	if !strings.ContainsAny(raw, `\$`) {
+		return raw, nil
+	}
+
+	d := decoder{quote: quote, lookup: lookup, command: command}
+
+	return d.decode(raw)
+}
+
+type decoder struct {
+	quote   byte
+	lookup  func(string) (string, bool)
+	command func(string) (string, error)
+}
+
+func (d decoder) decode(s string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch c = s[i]; {
+			case c == 'n' && d.quote == '"':
+				b.WriteByte('\n')
+			case c == 'r' && d.quote == '"':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(c)
+			}
+		case c == '$' && d.command != nil && strings.HasPrefix(s[i+1:], "("):
+			end := closingParen(s[i+1:])
+			if end == -1 {
+				b.WriteByte(c)
+				continue
+			}
+			output, err := d.command(s[i+2 : i+1+end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(output)
+			i += end + 1
+		case c == '$' && d.lookup != nil:
+			value, n, err := d.expand(s[i+1:])
+			if err != nil {
+				return "", err
+			}
+			if n == 0 {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(value)
+			i += n
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expand resolves the `NAME` or `{NAME...}` reference in the text following a `$`
+//
+// The length of the reference is returned as well and will be zero when there is no reference.
+func (d decoder) expand(s string) (string, int, error) {
+	if !strings.HasPrefix(s, "{") {
+		n := 0
+		for n < len(s) && isNameChar(s[n]) {
+			n++
+		}
+		if n == 0 {
+			return "", 0, nil
+		}
+		value, _ := d.lookup(s[:n])
+		return value, n, nil
+	}
+
+	end := closingBrace(s)
+	if end == -1 {
+		return "", 0, nil
+	}
+
+	name, op, word := splitParameter(s[1:end])
+	if !isName(name) {
+		return "", 0, nil
+	}
+
+	value, exists := d.lookup(name)
+	set := exists && (value != "" || !strings.HasPrefix(op, ":"))
+
+	switch op {
+	case "":
+		return value, end + 1, nil
+	case ":-", "-":
+		if set {
+			return value, end + 1, nil
+		}
+		value, err := d.decode(word)
+		return value, end + 1, err
+	case ":+", "+":
+		if !set {
+			return "", end + 1, nil
+		}
+		value, err := d.decode(word)
+		return value, end + 1, err
+	case ":?", "?":
+		if set {
+			return value, end + 1, nil
+		}
+		message, err := d.decode(word)
+		if err != nil {
+			return "", 0, err
+		}
+		return "", 0, &RequiredVariableError{Variable: name, Message: message}
+	}
+
+	// not a parameter expansion that is supported
+	return "", 0, nil
+}
+
+// closingBrace returns the offset of the brace that closes the `{` that s starts with, or -1
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			if i == 0 || s[i-1] == '$' {
+				depth++
+			}
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// closingParen returns the offset of the parenthesis that closes the `(` that s starts with, or -1
+//
+// Quoted text and escaped characters within the command are skipped over.
+func closingParen(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quote != '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitParameter splits the contents of a `${...}` expansion into the name, operator and word
+func splitParameter(s string) (string, string, string) {
+	n := 0
+	for n < len(s) && isNameChar(s[n]) {
+		n++
+	}
+	name, rest := s[:n], s[n:]
+
+	for _, op := range []string{":-", ":+", ":?", "-", "+", "?"} {
+		if strings.HasPrefix(rest, op) {
+			return name, op, rest[len(op):]
+		}
+	}
+	if rest != "" {
+		return name, rest, ""
+	}
+
+	return name, "", ""
+}
+
+func isName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+func isLineEnd(c byte) bool {
+	return c == '\n' || c == '\r'
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isKeyChar(c byte) bool {
+	return c == '.' || isNameChar(c)
+}
@@ -0,0 +1,73 @@
+package dotenv
+
+// RestoreFunc puts the Environment back the way it was before LoadWithRestore() changed it
+type RestoreFunc func() error
+
+// LoadWithRestore works like Load() and returns a RestoreFunc that undoes every change Load() made
+//
+// Variables that were replaced are set back to their previous values and variables that were added
+// are unset. When an error is returned the environment has already been restored.
+func LoadWithRestore(options ...LoadOption) (RestoreFunc, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.loadOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	snap := newSnapshot(cfg.env)
+	if err := load(cfg, snap); err != nil {
+		if restoreErr := snap.restore(); restoreErr != nil {
+			return nil, restoreErr
+		}
+		return nil, err
+	}
+
+	return snap.restore, nil
+}
+
+type previousValue struct {
+	value  string
+	exists bool
+}
+
+// snapshot records the values environment variables had before they were first changed
+type snapshot struct {
+	env      Environment
+	previous map[string]previousValue
+}
+
+func newSnapshot(env Environment) *snapshot {
+	return &snapshot{
+		env:      env,
+		previous: make(map[string]previousValue),
+	}
+}
+
+// record remembers the value of key in currentEnv unless an earlier value was already recorded
+func (s *snapshot) record(key string, currentEnv envVars) {
+	if _, recorded := s.previous[key]; recorded {
+		return
+	}
+
+	value, exists := currentEnv[key]
+	s.previous[key] = previousValue{value: value, exists: exists}
+}
+
+func (s *snapshot) restore() error {
+	for key, previous := range s.previous {
+		var err error
+		if previous.exists {
+			err = s.env.Set(key, previous.value)
+		} else {
+			err = s.env.Unset(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
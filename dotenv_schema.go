@@ -0,0 +1,250 @@
+package dotenv
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaKey describes the value expected for a key
+type schemaKey struct {
+	key          string
+	description  string
+	kind         string
+	required     bool
+	secret       bool
+	defaultValue string
+	hasDefault   bool
+	enum         []string
+	pattern      *regexp.Regexp
+}
+
+// Violation describes a value that does not satisfy the schema
+type Violation struct {
+	Key     string
+	Message string
+	// Description is the description of the key taken from the schema
+	Description string
+}
+
+// SchemaError is returned when values do not satisfy the schema and lists every violation
+type SchemaError struct {
+	// File is the path of the schema file
+	File       string
+	Violations []Violation
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Key + ": " + violation.Message
+	}
+
+	return fmt.Sprintf("values do not satisfy the schema %s: %s", e.File, strings.Join(messages, "; "))
+}
+
+// readSchema reads the keys described by the schema file
+//
+// The schema uses the same syntax as any other file. The value assigned to a key is its default, and
+// the comment lines directly above a key describe it. Comment lines starting with an annotation set
+// the rules for the key:
+//
+//	# The port the HTTP server listens on
+//	# @required
+//	# @type int
+//	PORT=8080
+//
+//	# @type enum debug,info,warn,error
+//	LOG_LEVEL=info
+//
+//	# @type regex ^[a-z][a-z0-9-]*$
+//	SERVICE_NAME=
+//
+// The types are string, int, bool, url, duration, enum and regex. Keys annotated with @secret are
+// redacted by Vars.
+func readSchema(cfg *envCfg) ([]schemaKey, error) {
+	fileName, err := cfg.absPath(cfg.schema)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := cfg.readFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(contents)
+	statements, err := lex(fileName, text)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]schemaKey, 0)
+	var comments []statement
+	for _, st := range statements {
+		switch st.kind {
+		case commentStatement:
+			comments = append(comments, st)
+			continue
+		case assignStatement:
+			if st.commentStart != -1 {
+				comments = append(comments, st)
+			}
+			key, err := newSchemaKey(fileName, text, st, comments)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		comments = nil
+	}
+
+	return keys, nil
+}
+
+func newSchemaKey(fileName, contents string, st statement, comments []statement) (schemaKey, error) {
+	key := schemaKey{key: st.key, kind: "string"}
+
+	value, err := decodeValue(contents[st.valueStart:st.valueEnd], st.quote, nil, nil)
+	if err != nil {
+		return key, err
+	}
+	key.defaultValue, key.hasDefault = value, value != ""
+
+	description := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		offset := comment.commentStart + 1
+		text := strings.TrimSpace(contents[offset:comment.end])
+		if !strings.HasPrefix(text, "@") {
+			description = append(description, text)
+			continue
+		}
+
+		annotation, argument := text, ""
+		if i := strings.IndexAny(text, " \t"); i != -1 {
+			annotation, argument = text[:i], strings.TrimSpace(text[i+1:])
+		}
+		offset += strings.Index(contents[offset:comment.end], annotation)
+
+		switch annotation {
+		case "@required":
+			key.required = true
+		case "@secret":
+			key.secret = true
+		case "@type":
+			if err := key.setType(argument); err != nil {
+				return key, newParseError(fileName, contents, offset, text, err.Error())
+			}
+		default:
+			return key, newParseError(fileName, contents, offset, text, "unknown annotation")
+		}
+	}
+	key.description = strings.Join(description, " ")
+
+	return key, nil
+}
+
+func (k *schemaKey) setType(argument string) error {
+	kind, rest := argument, ""
+	if i := strings.IndexAny(argument, " \t"); i != -1 {
+		kind, rest = argument[:i], strings.TrimSpace(argument[i+1:])
+	}
+
+	switch kind {
+	case "string", "int", "bool", "url", "duration":
+	case "enum":
+		if rest == "" {
+			return fmt.Errorf("enum type requires a list of values")
+		}
+		for _, value := range strings.Split(rest, ",") {
+			k.enum = append(k.enum, strings.TrimSpace(value))
+		}
+	case "regex":
+		pattern, err := regexp.Compile(rest)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %s", err)
+		}
+		k.pattern = pattern
+	default:
+		return fmt.Errorf("unknown type %q", kind)
+	}
+	k.kind = kind
+
+	return nil
+}
+
+// check returns a message describing why value does not satisfy the schema, or an empty string
+func (k schemaKey) check(value string) string {
+	switch k.kind {
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an int"
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be a bool"
+		}
+	case "url":
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" {
+			return "must be a url"
+		}
+	case "duration":
+		if _, err := time.ParseDuration(value); err != nil {
+			return "must be a duration"
+		}
+	case "enum":
+		for _, option := range k.enum {
+			if value == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(k.enum, ", ")
+	case "regex":
+		if !k.pattern.MatchString(value) {
+			return "must match " + k.pattern.String()
+		}
+	}
+
+	return ""
+}
+
+// applySchema validates envs against the schema and returns the defaults for any keys that are not set
+func applySchema(cfg *envCfg, envs envVars) (envVars, error) {
+	if cfg.schema == "" {
+		return envVars{}, nil
+	}
+
+	keys, err := readSchema(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := make(envVars)
+	violations := make([]Violation, 0)
+	for _, key := range keys {
+		value, exists := envs[key.key]
+		if !exists && key.hasDefault {
+			value = key.defaultValue
+			defaults[key.key] = value
+		}
+
+		message := ""
+		switch {
+		case value == "" && key.required:
+			message = "is required"
+		case value != "":
+			message = key.check(value)
+		}
+		if message != "" {
+			violations = append(violations, Violation{Key: key.key, Message: message, Description: key.description})
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, &SchemaError{File: cfg.schema, Violations: violations}
+	}
+
+	return defaults, nil
+}
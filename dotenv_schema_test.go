@@ -0,0 +1,181 @@
+package dotenv
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const testSchema = `# The port the HTTP server listens on
+# @required
+# @type int
+PORT=8080
+
+# @type enum debug,info,warn,error
+LOG_LEVEL=info
+
+# Where the data lives
+# @type url
+DATABASE_URL= # @required
+
+# @type regex ^[a-z][a-z0-9-]*$
+SERVICE_NAME=
+
+DEBUG=false # @type bool
+TIMEOUT= # @type duration
+`
+
+func TestSchema(t *testing.T) {
+	tests := map[string]struct {
+		contents string
+		env      map[string]string
+		schema   string
+		want     map[string]string
+		wantErr  []Violation
+	}{
+		"applies defaults": {
+			contents: "DATABASE_URL=postgres://localhost/db",
+			schema:   testSchema,
+			want: map[string]string{
+				"DATABASE_URL": "postgres://localhost/db",
+				"PORT":         "8080",
+				"LOG_LEVEL":    "info",
+				"DEBUG":        "false",
+			},
+		},
+		"accepts valid values": {
+			contents: "DATABASE_URL=postgres://localhost/db\nPORT=9000\nLOG_LEVEL=warn\nSERVICE_NAME=api-2\nDEBUG=1\nTIMEOUT=5s",
+			schema:   testSchema,
+			want: map[string]string{
+				"DATABASE_URL": "postgres://localhost/db",
+				"PORT":         "9000",
+				"LOG_LEVEL":    "warn",
+				"SERVICE_NAME": "api-2",
+				"DEBUG":        "1",
+				"TIMEOUT":      "5s",
+			},
+		},
+		"accepts keys set in the environment": {
+			contents: "LOG_LEVEL=warn",
+			env:      map[string]string{"DATABASE_URL": "postgres://localhost/db", "PORT": "9000"},
+			schema:   testSchema,
+			want: map[string]string{
+				"LOG_LEVEL": "warn",
+				"DEBUG":     "false",
+			},
+		},
+		"validates keys set in the environment": {
+			contents: "DATABASE_URL=postgres://localhost/db",
+			env:      map[string]string{"PORT": "http"},
+			schema:   testSchema,
+			wantErr: []Violation{
+				{Key: "PORT", Message: "must be an int", Description: "The port the HTTP server listens on"},
+			},
+		},
+		"reports every violation": {
+			contents: "PORT=http\nLOG_LEVEL=trace\nSERVICE_NAME=API\nDEBUG=maybe\nTIMEOUT=5 seconds",
+			schema:   testSchema,
+			wantErr: []Violation{
+				{Key: "PORT", Message: "must be an int", Description: "The port the HTTP server listens on"},
+				{Key: "LOG_LEVEL", Message: "must be one of debug, info, warn, error"},
+				{Key: "DATABASE_URL", Message: "is required", Description: "Where the data lives"},
+				{Key: "SERVICE_NAME", Message: "must match ^[a-z][a-z0-9-]*$"},
+				{Key: "DEBUG", Message: "must be a bool"},
+				{Key: "TIMEOUT", Message: "must be a duration"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			fsys := fstest.MapFS{
+				".env":        {Data: []byte(tt.contents)},
+				".env.schema": {Data: []byte(tt.schema)},
+			}
+			got, err := Parse(FS(fsys), Schema(".env.schema"))
+			if tt.wantErr != nil {
+				var schemaErr *SchemaError
+				if !errors.As(err, &schemaErr) {
+					t.Fatalf("Parse() error = %v, want a *SchemaError", err)
+				}
+				if !reflect.DeepEqual(schemaErr.Violations, tt.wantErr) {
+					t.Errorf("Parse() violations = %#v, want %#v", schemaErr.Violations, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	tests := map[string]struct {
+		schema string
+		want   ParseError
+	}{
+		"unknown types": {
+			schema: "# @type integer\nPORT=",
+			want:   ParseError{File: ".env.schema", Line: 1, Column: 3, Text: "@type integer", Reason: `unknown type "integer"`},
+		},
+		"invalid regex": {
+			schema: "PORT= # @type regex [\n",
+			want:   ParseError{File: ".env.schema", Line: 1, Column: 9, Text: "@type regex [", Reason: "invalid regex: error parsing regexp: missing closing ]: `[`"},
+		},
+		"unknown annotations": {
+			schema: "\n#   @deprecated\nPORT=",
+			want:   ParseError{File: ".env.schema", Line: 2, Column: 5, Text: "@deprecated", Reason: "unknown annotation"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{".env.schema": {Data: []byte(tt.schema)}}
+			_, err := Parse(FS(fsys), Schema(".env.schema"))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want a *ParseError", err)
+			}
+			if !reflect.DeepEqual(*parseErr, tt.want) {
+				t.Errorf("Parse() error = %#v, want %#v", *parseErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":        {Data: []byte("DATABASE_URL=postgres://localhost/db")},
+		".env.schema": {Data: []byte(testSchema)},
+	}
+
+	os.Clearenv()
+	t.Setenv("PORT", "9000")
+	if err := Load(FS(fsys), Schema(".env.schema")); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := envVars{
+		"DATABASE_URL": "postgres://localhost/db",
+		"PORT":         "9000",
+		"LOG_LEVEL":    "info",
+		"DEBUG":        "false",
+	}
+	if envs := systemEnvs(); !reflect.DeepEqual(envs, want) {
+		t.Errorf("ENV = %v, want %v", envs, want)
+	}
+
+	os.Clearenv()
+	t.Setenv("PORT", "http")
+	var schemaErr *SchemaError
+	if err := Load(FS(fsys), Schema(".env.schema")); !errors.As(err, &schemaErr) {
+		t.Errorf("Load() error = %v, want a *SchemaError", err)
+	}
+}
@@ -0,0 +1,172 @@
+package dotenv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SecretProvider resolves values that refer to secrets kept elsewhere, such as `file:///run/secrets/db`
+type SecretProvider interface {
+	// Scheme is the URL scheme of the references resolved by the provider
+	Scheme() string
+	// Resolve returns the secret that ref refers to
+	Resolve(ctx context.Context, ref *url.URL) (string, error)
+}
+
+// defaultSecretTimeout limits how long each reference may take to resolve
+const defaultSecretTimeout = 30 * time.Second
+
+// resolveSecret returns the secret when value refers to one handled by a provider; otherwise value is returned as it is
+func resolveSecret(cfg *envCfg, value string) (string, error) {
+	if len(cfg.providers) == 0 {
+		return value, nil
+	}
+
+	i := strings.Index(value, "://")
+	if i == -1 {
+		return value, nil
+	}
+	provider, exists := cfg.providers[strings.ToLower(value[:i])]
+	if !exists {
+		return value, nil
+	}
+
+	ref, err := url.Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultSecretTimeout)
+	defer cancel()
+
+	return provider.Resolve(ctx, ref)
+}
+
+// FileProvider resolves `file://` references by reading the file, such as a Docker or Kubernetes secret mount
+//
+// A single trailing newline is removed. When the reference has a fragment, such as
+// `file:///run/secrets/db.json#password`, the file is read as JSON and the fragment selects the field;
+// nested fields are separated by dots.
+type FileProvider struct{}
+
+func (FileProvider) Scheme() string {
+	return "file"
+}
+
+func (FileProvider) Resolve(_ context.Context, ref *url.URL) (string, error) {
+	if ref.Host != "" && ref.Host != "localhost" {
+		return "", fmt.Errorf("file references must be absolute paths, such as file:///run/secrets/db")
+	}
+
+	path := ref.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// file:///C:/secrets/db
+		path = path[1:]
+	}
+
+	contents, err := os.ReadFile(filepath.FromSlash(path))
+	if err != nil {
+		return "", err
+	}
+
+	return secretValue(contents, ref.Fragment)
+}
+
+// HTTPProvider resolves references using its own scheme with a GET request to a secrets service
+//
+// The host and path of the reference are joined to BaseURL, and the query is passed along. With
+// URLScheme "vault" and BaseURL "https://vault.internal/v1/secret/data", the reference
+// `vault://app/db?version=2#data.password` is read from
+// `https://vault.internal/v1/secret/data/app/db?version=2`. The fragment selects a field of the
+// JSON response, as it does for FileProvider; otherwise the whole response is used.
+type HTTPProvider struct {
+	// URLScheme is the scheme of the references to resolve; it should not be http or https so that
+	// ordinary URLs are not mistaken for references
+	URLScheme string
+	BaseURL   string
+	// Header is added to every request, such as an authorization token
+	Header http.Header
+	// Client is used to make the requests; http.DefaultClient is used when it is nil
+	Client *http.Client
+}
+
+func (p *HTTPProvider) Scheme() string {
+	return p.URLScheme
+}
+
+func (p *HTTPProvider) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	target := strings.TrimSuffix(p.BaseURL, "/") + "/" + strings.TrimPrefix(ref.Host+ref.Path, "/")
+	if ref.RawQuery != "" {
+		target += "?" + ref.RawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, values := range p.Header {
+		req.Header[key] = values
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return secretValue(body, ref.Fragment)
+}
+
+// secretValue returns the field of the JSON document in contents selected by the dot separated
+// fragment, or contents without a trailing newline when there is no fragment
+func secretValue(contents []byte, fragment string) (string, error) {
+	if fragment == "" {
+		value := strings.TrimSuffix(string(contents), "\n")
+		return strings.TrimSuffix(value, "\r"), nil
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(contents, &document); err != nil {
+		return "", fmt.Errorf("unable to select %q: %w", fragment, err)
+	}
+
+	for _, field := range strings.Split(fragment, ".") {
+		object, ok := document.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("unable to select %q: %q is not within an object", fragment, field)
+		}
+		if document, ok = object[field]; !ok {
+			return "", fmt.Errorf("unable to select %q: %q does not exist", fragment, field)
+		}
+	}
+
+	switch value := document.(type) {
+	case string:
+		return value, nil
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("unable to select %q: the value is not a string, number or bool", fragment)
+	default:
+		b, err := json.Marshal(value)
+		return string(b), err
+	}
+}
@@ -0,0 +1,228 @@
+package dotenv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type mapProvider map[string]string
+
+func (mapProvider) Scheme() string {
+	return "secret"
+}
+
+func (p mapProvider) Resolve(_ context.Context, ref *url.URL) (string, error) {
+	value, exists := p[ref.Host+ref.Path]
+	if !exists {
+		return "", errors.New("secret does not exist")
+	}
+
+	return value, nil
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"db":      "s3cr3t\n",
+		"crlf":    "s3cr3t\r\n",
+		"db.json": `{"password": "s3cr3t", "port": 5432, "nested": {"user": "app"}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := "file://" + filepath.ToSlash(dir)
+
+	tests := map[string]struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		"reads the file":                     {ref: base + "/db", want: "s3cr3t"},
+		"removes a trailing CRLF":            {ref: base + "/crlf", want: "s3cr3t"},
+		"selects a field":                    {ref: base + "/db.json#password", want: "s3cr3t"},
+		"selects a number":                   {ref: base + "/db.json#port", want: "5432"},
+		"selects a nested field":             {ref: base + "/db.json#nested.user", want: "app"},
+		"returns an error for missing files": {ref: base + "/missing", wantErr: true},
+		"returns an error for missing field": {ref: base + "/db.json#user", wantErr: true},
+		"returns an error for objects":       {ref: base + "/db.json#nested", wantErr: true},
+		"returns an error for invalid json":  {ref: base + "/db#password", wantErr: true},
+		"returns an error for remote hosts":  {ref: "file://server/db", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, err := url.Parse(tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := FileProvider{}.Resolve(context.Background(), ref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.String() {
+		case "/v1/secret/data/app/db?version=2":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "s3cr3t"}}}`))
+		case "/v1/secret/data/app/plain":
+			_, _ = w.Write([]byte("plain\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := &HTTPProvider{
+		URLScheme: "vault",
+		BaseURL:   server.URL + "/v1/secret/data/",
+		Header:    http.Header{"X-Vault-Token": []string{"token"}},
+		Client:    server.Client(),
+	}
+
+	tests := map[string]struct {
+		provider *HTTPProvider
+		ref      string
+		want     string
+		wantErr  bool
+	}{
+		"selects a field of the response": {
+			provider: provider,
+			ref:      "vault://app/db?version=2#data.data.password",
+			want:     "s3cr3t",
+		},
+		"uses the whole response": {
+			provider: provider,
+			ref:      "vault://app/plain",
+			want:     "plain",
+		},
+		"returns an error for unsuccessful responses": {
+			provider: provider,
+			ref:      "vault://app/missing",
+			wantErr:  true,
+		},
+		"returns an error when the request is rejected": {
+			provider: &HTTPProvider{URLScheme: "vault", BaseURL: server.URL + "/v1/secret/data", Client: server.Client()},
+			ref:      "vault://app/plain",
+			wantErr:  true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, err := url.Parse(tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.provider.Resolve(context.Background(), ref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("resolves references while parsing", func(t *testing.T) {
+		os.Clearenv()
+		got, err := ParseString("DB_PASSWORD=vault://app/db?version=2#data.data.password", Providers(provider))
+		if err != nil {
+			t.Fatalf("ParseString() error = %v", err)
+		}
+		if want := map[string]string{"DB_PASSWORD": "s3cr3t"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseString() got = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestProviders(t *testing.T) {
+	provider := mapProvider{"vault/app/db": "s3cr3t"}
+
+	type args struct {
+		contents string
+		options  []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		"leaves references alone without providers": {
+			args:    args{contents: "DB_PASSWORD=secret://vault/app/db"},
+			want:    map[string]string{"DB_PASSWORD": "secret://vault/app/db"},
+			wantErr: false,
+		},
+		"resolves references": {
+			args:    args{contents: "DB_PASSWORD=secret://vault/app/db", options: []ParseOption{Providers(provider)}},
+			want:    map[string]string{"DB_PASSWORD": "s3cr3t"},
+			wantErr: false,
+		},
+		"resolves references after expanding variables": {
+			args: args{
+				contents: "APP=app\nDB_PASSWORD=\"secret://vault/${APP}/db\"\nDSN=\"postgres://${APP}:${DB_PASSWORD}@db\"",
+				options:  []ParseOption{Providers(provider)},
+			},
+			want: map[string]string{
+				"APP":         "app",
+				"DB_PASSWORD": "s3cr3t",
+				"DSN":         "postgres://app:s3cr3t@db",
+			},
+			wantErr: false,
+		},
+		"leaves other schemes alone": {
+			args:    args{contents: "API_URL=https://example.com", options: []ParseOption{Providers(provider)}},
+			want:    map[string]string{"API_URL": "https://example.com"},
+			wantErr: false,
+		},
+		"returns an error for references that cannot be resolved": {
+			args:    args{contents: "A=1\nDB_PASSWORD=secret://vault/app/missing", options: []ParseOption{Providers(provider)}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			got, err := ParseString(tt.args.contents, tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseString() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("reports the key and line of the reference", func(t *testing.T) {
+		os.Clearenv()
+		_, err := ParseString("A=1\nDB_PASSWORD=secret://vault/app/missing", Providers(provider))
+		var secretErr *SecretError
+		if !errors.As(err, &secretErr) {
+			t.Fatalf("ParseString() error = %v, want a *SecretError", err)
+		}
+		want := &SecretError{Line: 2, Key: "DB_PASSWORD", Reference: "secret://vault/app/missing", Err: secretErr.Err}
+		if !reflect.DeepEqual(secretErr, want) {
+			t.Errorf("SecretError got = %+v, want %+v", secretErr, want)
+		}
+	})
+}
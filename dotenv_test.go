@@ -1,9 +1,15 @@
 package dotenv
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestParsing(t *testing.T) {
@@ -116,6 +122,50 @@ func TestParsing(t *testing.T) {
 			want:    envVars{"BAR": ""},
 			wantErr: false,
 		},
+		"expands default values for unset variables": {
+			args:    args{"FOO=${UNSET:-default}\nBAR=${EMPTY:-default}\nBAZ=${EMPTY-default}", false},
+			setEnvs: envVars{"EMPTY": ""},
+			want:    envVars{"FOO": "default", "BAR": "default", "BAZ": ""},
+			wantErr: false,
+		},
+		"does not expand default values for set variables": {
+			args:    args{"FOO=test\nBAR=${FOO:-default}\nBAZ=\"${FOO-default}\"", false},
+			want:    envVars{"FOO": "test", "BAR": "test", "BAZ": "test"},
+			wantErr: false,
+		},
+		"expands variables within default values": {
+			args:    args{"FOO=test\nBAR=\"${UNSET:-$FOO and ${OTHER:-\\}}}\"", false},
+			want:    envVars{"FOO": "test", "BAR": "test and }"},
+			wantErr: false,
+		},
+		"expands alternate values for set variables": {
+			args:    args{"FOO=test\nBAR=${FOO:+alt}\nBAZ=${EMPTY:+alt}\nQUX=${EMPTY+alt}\nQUUX=${UNSET+alt}", false},
+			setEnvs: envVars{"EMPTY": ""},
+			want:    envVars{"FOO": "test", "BAR": "alt", "BAZ": "", "QUX": "alt", "QUUX": ""},
+			wantErr: false,
+		},
+		"expands required variables that are set": {
+			args:    args{"FOO=test\nBAR=${FOO:?is required}\nBAZ=${EMPTY?is required}", false},
+			setEnvs: envVars{"EMPTY": ""},
+			want:    envVars{"FOO": "test", "BAR": "test", "BAZ": ""},
+			wantErr: false,
+		},
+		"returns an error for required variables that are empty": {
+			args:    args{"BAR=${EMPTY:?is required}", false},
+			setEnvs: envVars{"EMPTY": ""},
+			want:    nil,
+			wantErr: true,
+		},
+		"returns an error for required variables that are unset": {
+			args:    args{"BAR=${UNSET?is required}", false},
+			want:    nil,
+			wantErr: true,
+		},
+		"does not expand parameters in single quoted strings": {
+			args:    args{"BAR='${UNSET:?is required}'", false},
+			want:    envVars{"BAR": "${UNSET:?is required}"},
+			wantErr: false,
+		},
 		"expands variables in double quoted strings": {
 			args:    args{"FOO=test\nBAR=\"$FOO\"", false},
 			want:    envVars{"FOO": "test", "BAR": "test"},
@@ -181,10 +231,10 @@ func TestParsing(t *testing.T) {
 			want:    envVars{"FOO": "bar"},
 			wantErr: false,
 		},
-		"ignores lines that are not variable assignments": {
+		"returns an error for lines that are not variable assignments": {
 			args:    args{"lol$wut", false},
-			want:    envVars{},
-			wantErr: false,
+			want:    nil,
+			wantErr: true,
 		},
 		"ignores empty lines": {
 			args:    args{"\n \t  \nfoo=bar\n \nfizz=buzz", false},
@@ -278,17 +328,41 @@ one more line`,
 			},
 			wantErr: false,
 		},
-		// TODO support carriage return by itself
-		// "supports carriage return": {
-		// 	args:    args{"FOO=bar\rbaz=fbb", false, nil},
-		// 	want:    envVars{"FOO": "bar", "baz": "fbb"},
-		// 	wantErr: false,
-		// },
+		"supports carriage return": {
+			args:    args{"FOO=bar\rbaz=fbb", false},
+			want:    envVars{"FOO": "bar", "baz": "fbb"},
+			wantErr: false,
+		},
 		"supports carriage return combined with new line": {
 			args:    args{"FOO=bar\r\nbaz=fbb", false},
 			want:    envVars{"FOO": "bar", "baz": "fbb"},
 			wantErr: false,
 		},
+		"supports carriage return in multi-line values": {
+			args:    args{"FOO='bar\rbaz'\rfizz=buzz", false},
+			want:    envVars{"FOO": "bar\rbaz", "fizz": "buzz"},
+			wantErr: false,
+		},
+		"parses escaped backslashes before the closing quote": {
+			args:    args{`FOO="bar\\" # comment`, false},
+			want:    envVars{"FOO": `bar\`},
+			wantErr: false,
+		},
+		"skips the byte order mark": {
+			args:    args{"\ufeffFOO=bar", false},
+			want:    envVars{"FOO": "bar"},
+			wantErr: false,
+		},
+		"returns an error for unterminated quoted values": {
+			args:    args{"FOO=\"bar\nBAR=baz", false},
+			want:    nil,
+			wantErr: true,
+		},
+		"returns an error for text after quoted values": {
+			args:    args{`FOO="bar" baz`, false},
+			want:    nil,
+			wantErr: true,
+		},
 		"expands carriage return in quoted strings": {
 			args:    args{"FOO=\"bar\\rbaz\"", false},
 			want:    envVars{"FOO": "bar\rbaz"},
@@ -310,7 +384,7 @@ one more line`,
 			for key, value := range tt.setEnvs {
 				t.Setenv(key, value)
 			}
-			got, err := parseString(tt.args.contents, tt.args.overload)
+			got, err := parseString(&envCfg{overload: tt.args.overload}, "", tt.args.contents)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseString() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -322,6 +396,69 @@ one more line`,
 	}
 }
 
+func TestParseError(t *testing.T) {
+	tests := map[string]struct {
+		fileName string
+		contents string
+		want     ParseError
+	}{
+		"reports invalid lines": {
+			fileName: ".env",
+			contents: "FOO=bar\n\n  lol$wut\nBAR=baz",
+			want:     ParseError{File: ".env", Line: 3, Column: 3, Text: "lol$wut", Reason: "invalid line"},
+		},
+		"reports invalid lines after carriage returns": {
+			fileName: ".env",
+			contents: "FOO=bar\r\nBAR baz\r\n",
+			want:     ParseError{File: ".env", Line: 2, Column: 1, Text: "BAR baz", Reason: "invalid line"},
+		},
+		"reports lines after carriage returns": {
+			fileName: ".env",
+			contents: "FOO=bar\rBAR baz",
+			want:     ParseError{File: ".env", Line: 2, Column: 1, Text: "BAR baz", Reason: "invalid line"},
+		},
+		"reports unterminated quoted values": {
+			fileName: ".env",
+			contents: "FOO=bar\nBAR= 'baz\nBAZ=qux",
+			want:     ParseError{File: ".env", Line: 2, Column: 6, Text: "'baz", Reason: "unterminated quoted value"},
+		},
+		"reports unset exported variables": {
+			fileName: "testdata/.env",
+			contents: "# comment\nOPTION_A=2\nexport OH_NO_NOT_SET # not set",
+			want:     ParseError{File: "testdata/.env", Line: 3, Column: 1, Text: "export OH_NO_NOT_SET # not set", Reason: "unset variable"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseString(&envCfg{}, tt.fileName, tt.contents)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("parseString() error = %v, want a *ParseError", err)
+			}
+			if !reflect.DeepEqual(*parseErr, tt.want) {
+				t.Errorf("parseString() error = %#v, want %#v", *parseErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredVariableError(t *testing.T) {
+	os.Clearenv()
+	_, err := parseString(&envCfg{}, "testdata/.env", "FOO=bar\n\nBAR=\"${FOO} ${DATABASE_URL:?must be set}\"")
+
+	var requiredErr *RequiredVariableError
+	if !errors.As(err, &requiredErr) {
+		t.Fatalf("parseString() error = %v, want a *RequiredVariableError", err)
+	}
+	want := RequiredVariableError{File: "testdata/.env", Line: 3, Key: "BAR", Variable: "DATABASE_URL", Message: "must be set"}
+	if !reflect.DeepEqual(*requiredErr, want) {
+		t.Errorf("parseString() error = %#v, want %#v", *requiredErr, want)
+	}
+	if got, want := err.Error(), "testdata/.env:3: BAR: DATABASE_URL: must be set"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
 func TestLoad(t *testing.T) {
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -569,6 +706,34 @@ func TestParse(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		"keeps values from the environment": {
+			args:    args{options: []ParseOption{Files(".env")}},
+			setEnvs: envVars{"DOTENV": "false"},
+			want:    envVars{"DOTENV": "false"},
+			wantErr: false,
+		},
+		"overload replaces values from the environment": {
+			args:    args{options: []ParseOption{Files(".env"), Overload()}},
+			setEnvs: envVars{"DOTENV": "false"},
+			want:    envVars{"DOTENV": "true"},
+			wantErr: false,
+		},
+		"overload replaces values from earlier files": {
+			args:    args{options: []ParseOption{Files(".env.test", ".env"), Overload()}},
+			want:    envVars{"DOTENV": "true", "DOTENVTEST": "true"},
+			wantErr: false,
+		},
+		"required keys may be set by the environment": {
+			args:    args{options: []ParseOption{Files(".env"), RequiredKeys("DOTENV", "OTHER")}},
+			setEnvs: envVars{"OTHER": "true"},
+			want:    envVars{"DOTENV": "true"},
+			wantErr: false,
+		},
+		"returns an error when required keys are missing": {
+			args:    args{options: []ParseOption{Files(".env"), RequiredKeys("TEST")}},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -586,9 +751,535 @@ func TestParse(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Load() got = %v, want %v", got, tt.want)
 			}
-			envs := systemEnvs()
-			if len(envs) != 0 {
-				t.Errorf("ENV got = %v, want %v", envs, envVars{})
+			want := tt.setEnvs
+			if want == nil {
+				want = envVars{}
+			}
+			if envs := systemEnvs(); !reflect.DeepEqual(envs, want) {
+				t.Errorf("ENV got = %v, want %v", envs, want)
+			}
+		})
+	}
+}
+
+func TestFileList(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(pwd, "testdata")
+
+	type args struct {
+		options []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		"defaults to .env": {
+			args:    args{options: []ParseOption{Paths(dir)}},
+			want:    []string{filepath.Join(dir, ".env")},
+			wantErr: false,
+		},
+		"lists files for an environment": {
+			args: args{options: []ParseOption{Paths(dir), EnvironmentFiles("development")}},
+			want: []string{
+				filepath.Join(dir, ".env.development.local"),
+				filepath.Join(dir, ".env.local"),
+				filepath.Join(dir, ".env.development"),
+				filepath.Join(dir, ".env"),
+			},
+			wantErr: false,
+		},
+		"lists files that do not exist": {
+			args:    args{options: []ParseOption{Paths(dir, filepath.Join(dir, "nested")), Files(".env.does_not_exist")}},
+			want:    []string{filepath.Join(dir, ".env.does_not_exist"), filepath.Join(dir, "nested", ".env.does_not_exist")},
+			wantErr: false,
+		},
+		"returns an error for paths that do not exist": {
+			args:    args{options: []ParseOption{Paths(filepath.Join(dir, "does_not_exist"))}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := FileList(tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FileList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FileList() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func benchmarkContents(lines int) string {
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		switch i % 5 {
+		case 0:
+			fmt.Fprintf(&b, "# comment for block %d\n", i)
+		case 1:
+			fmt.Fprintf(&b, "export KEY_%d=plain_value_%d\n", i, i)
+		case 2:
+			fmt.Fprintf(&b, "KEY_%d=\"double quoted ${KEY_%d} with \\n escapes\" # trailing comment\n", i, i-1)
+		case 3:
+			fmt.Fprintf(&b, "KEY_%d='single quoted $KEY_%d'\n", i, i-2)
+		case 4:
+			fmt.Fprintf(&b, "KEY_%d: yaml style %d\n\n", i, i)
+		}
+	}
+
+	return b.String()
+}
+
+func BenchmarkParseString(b *testing.B) {
+	for _, lines := range []int{100, 1000, 10000} {
+		contents := benchmarkContents(lines)
+		b.Run(fmt.Sprintf("%d lines", lines), func(b *testing.B) {
+			b.SetBytes(int64(len(contents)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := parseString(&envCfg{}, "", contents); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestParseReader(t *testing.T) {
+	type args struct {
+		reader  io.Reader
+		options []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		setEnvs envVars
+		want    map[string]string
+		wantErr bool
+	}{
+		"parses variables from the reader": {
+			args:    args{reader: strings.NewReader("FOO=bar\nBAR=${FOO}baz")},
+			want:    envVars{"FOO": "bar", "BAR": "barbaz"},
+			wantErr: false,
+		},
+		"does not overwrite ENV": {
+			args:    args{reader: strings.NewReader("FOO=bar\nBAR=baz")},
+			setEnvs: envVars{"FOO": "predefined"},
+			want:    envVars{"FOO": "predefined", "BAR": "baz"},
+			wantErr: false,
+		},
+		"ignores file options": {
+			args:    args{reader: strings.NewReader("FOO=bar"), options: []ParseOption{Files(".env.does_not_exist"), AllFilesRequired()}},
+			want:    envVars{"FOO": "bar"},
+			wantErr: false,
+		},
+		"returns parse errors": {
+			args:    args{reader: strings.NewReader("lol$wut")},
+			want:    nil,
+			wantErr: true,
+		},
+		"returns read errors": {
+			args:    args{reader: errReader{}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.setEnvs {
+				t.Setenv(key, value)
+			}
+			got, err := ParseReader(tt.args.reader, tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseReader() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReader() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":             {Data: []byte("DOTENV=true")},
+		".env.local":       {Data: []byte("DOTENVLOCAL=true\nDOTENV=local")},
+		".env.development": {Data: []byte("DOTENVDEVELOPMENT=true\nDOTENV=dev")},
+		"config/.env":      {Data: []byte("NESTED=true")},
+	}
+
+	type args struct {
+		options []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		"defaults to loading .env": {
+			args:    args{options: []ParseOption{FS(fsys)}},
+			want:    envVars{"DOTENV": "true"},
+			wantErr: false,
+		},
+		"load variables for an environment": {
+			args: args{options: []ParseOption{FS(fsys), EnvironmentFiles("development")}},
+			want: envVars{
+				"DOTENV":            "local",
+				"DOTENVDEVELOPMENT": "true",
+				"DOTENVLOCAL":       "true",
+			},
+			wantErr: false,
+		},
+		"load variables from files in multiple paths": {
+			args:    args{options: []ParseOption{FS(fsys), Paths(".", "./config")}},
+			want:    envVars{"DOTENV": "true", "NESTED": "true"},
+			wantErr: false,
+		},
+		"returns an error when required files do not exist": {
+			args:    args{options: []ParseOption{FS(fsys), Files(".env", ".env.does_not_exist"), AllFilesRequired()}},
+			want:    nil,
+			wantErr: true,
+		},
+		"returns an error when the path does not exist": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("missing")}},
+			want:    nil,
+			wantErr: true,
+		},
+		"returns an error for paths outside of the filesystem": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("../testdata")}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			got, err := Parse(tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("load variables into ENV", func(t *testing.T) {
+		os.Clearenv()
+		if err := Load(FS(fsys), Paths("config")); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		want := envVars{"NESTED": "true"}
+		if envs := systemEnvs(); !reflect.DeepEqual(envs, want) {
+			t.Errorf("ENV = %v, want %v", envs, want)
+		}
+	})
+}
+
+func TestSearchParents(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":                              {Data: []byte("OUTSIDE=true")},
+		"project/go.mod":                    {Data: []byte("module project")},
+		"project/.env":                      {Data: []byte("DOTENV=true")},
+		"project/.env.development":          {Data: []byte("DOTENVDEVELOPMENT=true\nDOTENV=dev")},
+		"project/internal/foo/foo.go":       {Data: []byte("package foo")},
+		"project/internal/bar/.env":         {Data: []byte("BAR=true")},
+		"project/internal/bar/bar.go":       {Data: []byte("package bar")},
+		"library/internal/baz/baz.go":       {Data: []byte("package baz")},
+		"library/.git/HEAD":                 {Data: []byte("ref: refs/heads/main")},
+		"unmarked/internal/qux/deep/qux.go": {Data: []byte("package qux")},
+	}
+
+	type args struct {
+		options []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		"does not search parents by default": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("project/internal/foo")}},
+			want:    envVars{},
+			wantErr: false,
+		},
+		"finds the files in a parent": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("project/internal/foo"), SearchParents()}},
+			want:    envVars{"DOTENV": "true"},
+			wantErr: false,
+		},
+		"finds the closest files": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("project/internal/bar"), SearchParents()}},
+			want:    envVars{"BAR": "true"},
+			wantErr: false,
+		},
+		"reads the environment files from the directory found": {
+			args: args{options: []ParseOption{FS(fsys), Paths("project/internal/foo"), EnvironmentFiles("development"), SearchParents()}},
+			want: envVars{
+				"DOTENV":            "dev",
+				"DOTENVDEVELOPMENT": "true",
+			},
+			wantErr: false,
+		},
+		"stops at a marker": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("library/internal/baz"), SearchParents()}},
+			want:    envVars{},
+			wantErr: false,
+		},
+		"stops at the root": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("unmarked/internal/qux/deep"), SearchParents()}},
+			want:    envVars{"OUTSIDE": "true"},
+			wantErr: false,
+		},
+		"stops at the maximum depth": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("unmarked/internal/qux/deep"), SearchParentsUntil(3)}},
+			want:    envVars{},
+			wantErr: false,
+		},
+		"finds the files within the maximum depth": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("unmarked/internal/qux/deep"), SearchParentsUntil(4)}},
+			want:    envVars{"OUTSIDE": "true"},
+			wantErr: false,
+		},
+		"returns an error when required files are not found": {
+			args:    args{options: []ParseOption{FS(fsys), Paths("library/internal/baz"), SearchParents(), AllFilesRequired()}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			got, err := Parse(tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("searches the operating system filesystem", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		nested := filepath.Join(dir, "internal", "foo")
+		if err := os.MkdirAll(nested, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for name, contents := range map[string]string{"go.mod": "module project", ".env": "DOTENV=true"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := Parse(Paths(nested), SearchParents())
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if want := map[string]string{"DOTENV": "true"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() got = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestParseDetailed(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(dir string) {
+		err := os.Chdir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}(pwd)
+
+	tests := map[string]struct {
+		setEnvs envVars
+		want    map[string]Detail
+	}{
+		"reports the file and shadowed definitions": {
+			want: map[string]Detail{
+				"DOTENV": {
+					Key:   "DOTENV",
+					Value: "development-local",
+					File:  ".env.development.local",
+					Line:  2,
+					Shadowed: []Definition{
+						{Key: "DOTENV", Value: "local", File: ".env.local", Line: 2},
+						{Key: "DOTENV", Value: "dev", File: ".env.development", Line: 2},
+						{Key: "DOTENV", Value: "true", File: ".env", Line: 1},
+					},
+				},
+				"DOTENVDEVELOPMENT": {
+					Key:      "DOTENVDEVELOPMENT",
+					Value:    "true",
+					File:     ".env.development",
+					Line:     1,
+					Shadowed: []Definition{},
+				},
+				"DOTENVDEVELOPMENTLOCAL": {
+					Key:      "DOTENVDEVELOPMENTLOCAL",
+					Value:    "true",
+					File:     ".env.development.local",
+					Line:     1,
+					Shadowed: []Definition{},
+				},
+				"DOTENVLOCAL": {
+					Key:      "DOTENVLOCAL",
+					Value:    "true",
+					File:     ".env.local",
+					Line:     1,
+					Shadowed: []Definition{},
+				},
+			},
+		},
+		"reports values from ENV": {
+			setEnvs: envVars{"DOTENVLOCAL": "env"},
+			want: map[string]Detail{
+				"DOTENVLOCAL": {
+					Key:             "DOTENVLOCAL",
+					Value:           "env",
+					FromEnvironment: true,
+					Shadowed: []Definition{
+						{Key: "DOTENVLOCAL", Value: "true", File: ".env.local", Line: 1},
+					},
+				},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.setEnvs {
+				t.Setenv(key, value)
+			}
+			got, err := ParseDetailed(EnvironmentFiles("development"))
+			if err != nil {
+				t.Fatalf("ParseDetailed() error = %v", err)
+			}
+			for key, want := range tt.want {
+				detail := got[key]
+				// compare file names relative to testdata
+				detail.File = filepath.Base(detail.File)
+				if detail.File == "." {
+					detail.File = ""
+				}
+				for i := range detail.Shadowed {
+					detail.Shadowed[i].File = filepath.Base(detail.Shadowed[i].File)
+				}
+				if !reflect.DeepEqual(detail, want) {
+					t.Errorf("ParseDetailed()[%s] got = %#v, want %#v", key, detail, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadWithRestore(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(dir string) {
+		err := os.Chdir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}(pwd)
+
+	type args struct {
+		options []LoadOption
+	}
+	tests := map[string]struct {
+		args    args
+		setEnvs envVars
+		want    envVars
+		wantErr bool
+	}{
+		"restores added variables": {
+			args:    args{options: []LoadOption{Files(".env", "plain.env")}},
+			setEnvs: envVars{"OPTION_A": "predefined"},
+			want: envVars{
+				"PLAIN":    "true",
+				"OPTION_A": "predefined",
+				"OPTION_B": "2",
+				"OPTION_C": "3",
+				"OPTION_D": "4",
+				"OPTION_E": "5",
+				"DOTENV":   "true",
+			},
+			wantErr: false,
+		},
+		"restores overloaded variables": {
+			args:    args{options: []LoadOption{EnvironmentFiles("development"), Overload()}},
+			setEnvs: envVars{"DOTENV": "false", "OTHER": "true"},
+			want: envVars{
+				"DOTENV":                 "true",
+				"DOTENVDEVELOPMENT":      "true",
+				"DOTENVDEVELOPMENTLOCAL": "true",
+				"DOTENVLOCAL":            "true",
+				"OTHER":                  "true",
+			},
+			wantErr: false,
+		},
+		"restores variables when an error is returned": {
+			args:    args{options: []LoadOption{Files(".env"), RequiredKeys("TEST")}},
+			setEnvs: envVars{"OTHER": "true"},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.setEnvs {
+				t.Setenv(key, value)
+			}
+			restore, err := LoadWithRestore(tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadWithRestore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				if envs := systemEnvs(); !reflect.DeepEqual(envs, tt.want) {
+					t.Errorf("ENV = %v, want %v", envs, tt.want)
+				}
+				if err := restore(); err != nil {
+					t.Errorf("restore() error = %v", err)
+				}
+			}
+			want := tt.setEnvs
+			if want == nil {
+				want = envVars{}
+			}
+			if envs := systemEnvs(); !reflect.DeepEqual(envs, want) {
+				t.Errorf("restored ENV = %v, want %v", envs, want)
 			}
 		})
 	}
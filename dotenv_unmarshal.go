@@ -0,0 +1,288 @@
+package dotenv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unmarshal sets the fields of the struct pointed to by v from vars
+//
+// Fields are matched to keys with the `env` struct tag. The tag holds the key followed by any of
+// these comma separated options:
+//
+//	required   an error is reported when the key is not set
+//	sep=;      the separator used to split the value of slice fields; the default is ","
+//	prefix=DB_ the prefix added to the keys of the fields of a nested struct
+//	default=x  the value used when the key is not set; it must be the last option
+//
+// For example:
+//
+//	type Config struct {
+//		DatabaseURL url.URL       `env:"DATABASE_URL,required"`
+//		Port        int           `env:"PORT,default=8080"`
+//		Timeout     time.Duration `env:"TIMEOUT,default=5s"`
+//		Hosts       []string      `env:"HOSTS,sep=;"`
+//		Cache       CacheConfig   `env:",prefix=CACHE_"`
+//	}
+//
+// Strings, bools, integers, floats, time.Duration, url.URL, encoding.TextUnmarshaler implementations,
+// slices and pointers of those types are supported. Nested structs without a tag are also searched,
+// without a prefix.
+//
+// Every failure is collected into a single *UnmarshalError.
+func Unmarshal(vars map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal requires a non-nil pointer to a struct, got %T", v)
+	}
+
+	u := &unmarshaler{vars: vars}
+	u.unmarshalFields(rv.Elem(), "", "")
+	if len(u.errs) > 0 {
+		return &UnmarshalError{Errors: u.errs}
+	}
+
+	return nil
+}
+
+// LoadInto works like Load() and then unmarshals the resulting environment into v
+//
+// See Unmarshal for the supported struct tags and field types.
+func LoadInto(v interface{}, options ...LoadOption) error {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.loadOption(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := load(cfg, nil); err != nil {
+		return err
+	}
+
+	return Unmarshal(cfg.currentEnvs(), v)
+}
+
+// FieldError describes a key that could not be unmarshaled into a struct field
+type FieldError struct {
+	// Key is the key that was being unmarshaled
+	Key string
+	// Field is the path to the struct field, such as "Cache.TTL"
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", e.Key, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalError is returned by Unmarshal and LoadInto and lists every key that could not be unmarshaled
+type UnmarshalError struct {
+	Errors []*FieldError
+}
+
+func (e *UnmarshalError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("unable to unmarshal %d key(s): %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// ErrRequiredKey is reported by a FieldError when a required key is not set
+var ErrRequiredKey = errors.New("required key is not set")
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type fieldTag struct {
+	key          string
+	required     bool
+	separator    string
+	prefix       string
+	defaultValue string
+	hasDefault   bool
+}
+
+func parseFieldTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{key: parts[0], separator: ","}
+
+	for i := 1; i < len(parts); i++ {
+		switch part := parts[i]; {
+		case part == "required":
+			ft.required = true
+		case strings.HasPrefix(part, "sep="):
+			ft.separator = strings.TrimPrefix(part, "sep=")
+		case strings.HasPrefix(part, "prefix="):
+			ft.prefix = strings.TrimPrefix(part, "prefix=")
+		case strings.HasPrefix(part, "default="):
+			// the default takes the remainder of the tag so that it may contain commas
+			ft.defaultValue = strings.TrimPrefix(strings.Join(parts[i:], ","), "default=")
+			ft.hasDefault = true
+			return ft
+		}
+	}
+
+	return ft
+}
+
+type unmarshaler struct {
+	vars map[string]string
+	errs []*FieldError
+}
+
+func (u *unmarshaler) unmarshalFields(rv reflect.Value, prefix, path string) {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		fv := rv.Field(i)
+
+		tag, tagged := field.Tag.Lookup("env")
+		ft := parseFieldTag(tag)
+
+		if isNestedStruct(field.Type) && (!tagged || ft.key == "") {
+			if field.Type.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			u.unmarshalFields(fv, prefix+ft.prefix, fieldPath)
+			continue
+		}
+
+		if !tagged || ft.key == "" {
+			continue
+		}
+
+		key := prefix + ft.key
+		value, exists := u.vars[key]
+		if !exists && ft.hasDefault {
+			value, exists = ft.defaultValue, true
+		}
+		if !exists {
+			if ft.required {
+				u.errs = append(u.errs, &FieldError{Key: key, Field: fieldPath, Err: ErrRequiredKey})
+			}
+			continue
+		}
+
+		if err := setValue(fv, value, ft.separator); err != nil {
+			u.errs = append(u.errs, &FieldError{Key: key, Field: fieldPath, Err: err})
+		}
+	}
+}
+
+// isNestedStruct reports whether fields of type t are searched for tags of their own
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && t != urlType && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func setValue(fv reflect.Value, value, separator string) error {
+	if fv.Kind() == reflect.Ptr {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), value, separator); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch fv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	case urlType:
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		if value == "" {
+			fv.Set(reflect.MakeSlice(fv.Type(), 0, 0))
+			return nil
+		}
+		items := strings.Split(value, separator)
+		slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item), separator); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		fv.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package dotenv
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testCacheConfig struct {
+	TTL   time.Duration `env:"TTL,default=1m"`
+	Hosts []string      `env:"HOSTS,sep=;"`
+}
+
+type testConfig struct {
+	DatabaseURL url.URL          `env:"DATABASE_URL,required"`
+	Callback    *url.URL         `env:"CALLBACK_URL"`
+	Port        int              `env:"PORT,default=8080"`
+	Debug       bool             `env:"DEBUG"`
+	Ratio       float64          `env:"RATIO"`
+	Workers     *uint8           `env:"WORKERS"`
+	IP          net.IP           `env:"IP"`
+	Ports       []int            `env:"PORTS"`
+	Greeting    string           `env:"GREETING,default=hello, world"`
+	Cache       testCacheConfig  `env:",prefix=CACHE_"`
+	Backup      *testCacheConfig `env:",prefix=BACKUP_"`
+	Embedded    struct {
+		Name string `env:"NAME"`
+	}
+	Untagged string
+	private  string `env:"PRIVATE"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	workers := uint8(4)
+	tests := map[string]struct {
+		vars     map[string]string
+		want     testConfig
+		wantKeys []string
+	}{
+		"sets fields from tags": {
+			vars: map[string]string{
+				"DATABASE_URL":   "postgres://localhost:5432/db",
+				"CALLBACK_URL":   "https://example.com/callback",
+				"PORT":           "9000",
+				"DEBUG":          "true",
+				"RATIO":          "0.5",
+				"WORKERS":        "4",
+				"IP":             "127.0.0.1",
+				"PORTS":          "80, 443",
+				"CACHE_TTL":      "5s",
+				"CACHE_HOSTS":    "a;b",
+				"BACKUP_TTL":     "1h",
+				"NAME":           "embedded",
+				"Untagged":       "ignored",
+				"PRIVATE":        "ignored",
+				"UNRELATED_NAME": "ignored",
+			},
+			want: testConfig{
+				DatabaseURL: url.URL{Scheme: "postgres", Host: "localhost:5432", Path: "/db"},
+				Callback:    &url.URL{Scheme: "https", Host: "example.com", Path: "/callback"},
+				Port:        9000,
+				Debug:       true,
+				Ratio:       0.5,
+				Workers:     &workers,
+				IP:          net.ParseIP("127.0.0.1"),
+				Ports:       []int{80, 443},
+				Greeting:    "hello, world",
+				Cache:       testCacheConfig{TTL: 5 * time.Second, Hosts: []string{"a", "b"}},
+				Backup:      &testCacheConfig{TTL: time.Hour},
+				Embedded: struct {
+					Name string `env:"NAME"`
+				}{Name: "embedded"},
+			},
+		},
+		"collects every error": {
+			vars: map[string]string{
+				"PORT":         "http",
+				"DEBUG":        "maybe",
+				"PORTS":        "80,x",
+				"CACHE_TTL":    "5 seconds",
+				"WORKERS":      "256",
+				"BACKUP_TTL":   "1h",
+				"CALLBACK_URL": "https://example.com/callback",
+			},
+			wantKeys: []string{"DATABASE_URL", "PORT", "DEBUG", "WORKERS", "PORTS", "CACHE_TTL"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got testConfig
+			err := Unmarshal(tt.vars, &got)
+			if tt.wantKeys == nil {
+				if err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Unmarshal() got = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+
+			var unmarshalErr *UnmarshalError
+			if !errors.As(err, &unmarshalErr) {
+				t.Fatalf("Unmarshal() error = %v, want an *UnmarshalError", err)
+			}
+			keys := make([]string, len(unmarshalErr.Errors))
+			for i, fieldErr := range unmarshalErr.Errors {
+				keys[i] = fieldErr.Key
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("Unmarshal() error keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if !errors.Is(unmarshalErr.Errors[0], ErrRequiredKey) {
+				t.Errorf("Unmarshal() error = %v, want ErrRequiredKey", unmarshalErr.Errors[0])
+			}
+		})
+	}
+}
+
+func TestUnmarshalRequiresStructPointer(t *testing.T) {
+	var cfg testConfig
+	for _, v := range []interface{}{nil, cfg, (*testConfig)(nil), new(string)} {
+		if err := Unmarshal(map[string]string{}, v); err == nil {
+			t.Errorf("Unmarshal(%T) error = nil, want an error", v)
+		}
+	}
+}
+
+func TestLoadInto(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(dir string) {
+		err := os.Chdir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}(pwd)
+
+	os.Clearenv()
+	t.Setenv("OPTION_A", "10")
+
+	var cfg struct {
+		Plain   bool `env:"PLAIN,required"`
+		OptionA int  `env:"OPTION_A"`
+		OptionE int  `env:"OPTION_E"`
+	}
+	if err := LoadInto(&cfg, Files("plain.env")); err != nil {
+		t.Fatalf("LoadInto() error = %v", err)
+	}
+	if !cfg.Plain || cfg.OptionA != 10 || cfg.OptionE != 5 {
+		t.Errorf("LoadInto() got = %+v", cfg)
+	}
+	if os.Getenv("OPTION_E") != "5" {
+		t.Errorf("LoadInto() did not load the environment")
+	}
+}
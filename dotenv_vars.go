@@ -0,0 +1,189 @@
+package dotenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// redactedValue replaces the values of secrets
+const redactedValue = "[REDACTED]"
+
+// defaultSecretPatterns match the keys that are treated as secrets unless SecretPatterns() is used
+var defaultSecretPatterns = []string{"*_SECRET", "*_PASSWORD", "*_TOKEN"}
+
+// Vars holds parsed values and knows which of them are secrets
+//
+// Formatting, logging with log/slog and marshalling to JSON all use the Redacted() values, so secrets are
+// not written out by accident. Use Get() or Map() to read the actual values.
+type Vars struct {
+	values  map[string]string
+	secrets map[string]bool
+}
+
+// NewVars returns Vars for values, treating the keys matching the default secret patterns and any
+// secretKeys as secrets
+func NewVars(values map[string]string, secretKeys ...string) Vars {
+	return newVars(values, defaultSecretPatterns, secretKeys)
+}
+
+func newVars(values map[string]string, patterns, secretKeys []string) Vars {
+	v := Vars{values: make(map[string]string, len(values)), secrets: make(map[string]bool)}
+
+	for key, value := range values {
+		v.values[key] = value
+		if matchesSecretPattern(key, patterns) {
+			v.secrets[key] = true
+		}
+	}
+	for _, key := range secretKeys {
+		v.secrets[key] = true
+	}
+
+	return v
+}
+
+// matchesSecretPattern reports whether key matches any of the patterns, ignoring case
+func matchesSecretPattern(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(key)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSecretComment reports whether the comment of st marks an assignment as secret
+func isSecretComment(contents string, st statement) bool {
+	fields := strings.Fields(contents[st.commentStart+1 : st.end])
+
+	return len(fields) > 0 && fields[0] == "@secret"
+}
+
+// ParseVars works like Parse() but returns Vars
+//
+// Keys are secrets when they match the secret patterns, which are *_SECRET, *_PASSWORD and *_TOKEN unless
+// the SecretPatterns() option is used, or when they are marked with an `@secret` comment, either in a
+// file or in the schema:
+//
+//	# @secret
+//	STRIPE_KEY=sk_live_...
+//	SIGNING_KEY=... # @secret
+func ParseVars(options ...ParseOption) (Vars, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.parseOption(cfg)
+		if err != nil {
+			return Vars{}, err
+		}
+	}
+
+	details, err := parseDetailed(cfg)
+	if err != nil {
+		return Vars{}, err
+	}
+	values, err := completeEnvs(cfg, detailEnvs(details))
+	if err != nil {
+		return Vars{}, err
+	}
+
+	secretKeys := make([]string, 0)
+	for key, detail := range details {
+		if detail.Secret {
+			secretKeys = append(secretKeys, key)
+		}
+	}
+	if cfg.schema != "" {
+		keys, err := readSchema(cfg)
+		if err != nil {
+			return Vars{}, err
+		}
+		for _, key := range keys {
+			if key.secret {
+				secretKeys = append(secretKeys, key.key)
+			}
+		}
+	}
+
+	patterns := cfg.secretPatterns
+	if patterns == nil {
+		patterns = defaultSecretPatterns
+	}
+
+	return newVars(values, patterns, secretKeys), nil
+}
+
+// Get returns the actual value of key and whether it is set
+func (v Vars) Get(key string) (string, bool) {
+	value, exists := v.values[key]
+
+	return value, exists
+}
+
+// IsSecret reports whether key is a secret
+func (v Vars) IsSecret(key string) bool {
+	return v.secrets[key]
+}
+
+// Keys returns the keys in sorted order
+func (v Vars) Keys() []string {
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Map returns the actual values
+func (v Vars) Map() map[string]string {
+	return mergeEnvs(v.values)
+}
+
+// Redacted returns the values with those of secrets replaced by "[REDACTED]"
+//
+// Secrets that are empty are left empty so that a missing secret can still be seen.
+func (v Vars) Redacted() map[string]string {
+	redacted := make(map[string]string, len(v.values))
+
+	for key, value := range v.values {
+		if v.secrets[key] && value != "" {
+			value = redactedValue
+		}
+		redacted[key] = value
+	}
+
+	return redacted
+}
+
+// String returns the redacted values as sorted KEY=value pairs
+func (v Vars) String() string {
+	redacted := v.Redacted()
+	pairs := make([]string, 0, len(redacted))
+	for _, key := range v.Keys() {
+		pairs = append(pairs, key+"="+redacted[key])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// Format implements fmt.Formatter so that every verb, including %#v, prints the redacted values
+func (v Vars) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		_, _ = fmt.Fprint(f, strconv.Quote(v.String()))
+	default:
+		_, _ = fmt.Fprint(f, v.String())
+	}
+}
+
+// MarshalJSON implements json.Marshaler and returns the redacted values as an object
+func (v Vars) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Redacted())
+}
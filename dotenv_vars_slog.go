@@ -0,0 +1,18 @@
+//go:build go1.21
+
+package dotenv
+
+import (
+	"log/slog"
+)
+
+// LogValue implements slog.LogValuer and logs the redacted values as a group
+func (v Vars) LogValue() slog.Value {
+	redacted := v.Redacted()
+	attrs := make([]slog.Attr, 0, len(redacted))
+	for _, key := range v.Keys() {
+		attrs = append(attrs, slog.String(key, redacted[key]))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,27 @@
+//go:build go1.21
+
+package dotenv
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestVarsLogValue(t *testing.T) {
+	vars := NewVars(map[string]string{"APP_NAME": "app", "DB_PASSWORD": "hunter2", "LICENSE": "lic-456"}, "LICENSE")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("config", "env", vars)
+	if got, wantLog := buf.String(), `{"level":"INFO","msg":"config","env":{"APP_NAME":"app","DB_PASSWORD":"[REDACTED]","LICENSE":"[REDACTED]"}}`+"\n"; got != wantLog {
+		t.Errorf("slog got = %s, want %s", got, wantLog)
+	}
+}
@@ -0,0 +1,132 @@
+package dotenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var varsFS = fstest.MapFS{
+	".env": {Data: []byte(strings.Join([]string{
+		"APP_NAME=app",
+		"DB_PASSWORD=hunter2",
+		"api_token=abc123",
+		"EMPTY_SECRET=",
+		"# @secret",
+		"STRIPE_KEY=sk_live_123",
+		"SIGNING_KEY=xyz789 # @secret",
+		"# @secret",
+		"",
+		"PLAIN=1",
+	}, "\n"))},
+	".env.schema": {Data: []byte("# @secret\nLICENSE=lic-456\n")},
+}
+
+func TestParseVars(t *testing.T) {
+	type args struct {
+		options []ParseOption
+	}
+	tests := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		"redacts keys matching the default patterns and marked as secret": {
+			args: args{options: []ParseOption{FS(varsFS)}},
+			want: map[string]string{
+				"APP_NAME":     "app",
+				"DB_PASSWORD":  redactedValue,
+				"api_token":    redactedValue,
+				"EMPTY_SECRET": "",
+				"STRIPE_KEY":   redactedValue,
+				"SIGNING_KEY":  redactedValue,
+				"PLAIN":        "1",
+			},
+			wantErr: false,
+		},
+		"redacts keys marked as secret in the schema": {
+			args: args{options: []ParseOption{FS(varsFS), Schema(".env.schema")}},
+			want: map[string]string{
+				"APP_NAME":     "app",
+				"DB_PASSWORD":  redactedValue,
+				"api_token":    redactedValue,
+				"EMPTY_SECRET": "",
+				"STRIPE_KEY":   redactedValue,
+				"SIGNING_KEY":  redactedValue,
+				"PLAIN":        "1",
+				"LICENSE":      redactedValue,
+			},
+			wantErr: false,
+		},
+		"uses the secret patterns": {
+			args: args{options: []ParseOption{FS(varsFS), SecretPatterns("APP_*")}},
+			want: map[string]string{
+				"APP_NAME":     redactedValue,
+				"DB_PASSWORD":  "hunter2",
+				"api_token":    "abc123",
+				"EMPTY_SECRET": "",
+				"STRIPE_KEY":   redactedValue,
+				"SIGNING_KEY":  redactedValue,
+				"PLAIN":        "1",
+			},
+			wantErr: false,
+		},
+		"returns an error when the files cannot be parsed": {
+			args:    args{options: []ParseOption{FS(varsFS), Files(".env", "missing"), AllFilesRequired()}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			vars, err := ParseVars(tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseVars() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := vars.Redacted(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Redacted() got = %v, want %v", got, tt.want)
+			}
+			if value, _ := vars.Get("DB_PASSWORD"); value != "hunter2" {
+				t.Errorf("Get() got = %q, want %q", value, "hunter2")
+			}
+		})
+	}
+}
+
+func TestVarsOutput(t *testing.T) {
+	vars := NewVars(map[string]string{"APP_NAME": "app", "DB_PASSWORD": "hunter2", "LICENSE": "lic-456"}, "LICENSE")
+	want := "APP_NAME=app DB_PASSWORD=[REDACTED] LICENSE=[REDACTED]"
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%d"} {
+		if got := fmt.Sprintf(format, vars); got != want {
+			t.Errorf("Sprintf(%q) got = %q, want %q", format, got, want)
+		}
+	}
+	if got := fmt.Sprintf("%q", vars); got != `"`+want+`"` {
+		t.Errorf("Sprintf(%%q) got = %s, want %q", got, want)
+	}
+	if got := fmt.Sprintf("%v", &vars); got != want {
+		t.Errorf("Sprintf(%%v) of a pointer got = %q, want %q", got, want)
+	}
+
+	b, err := json.Marshal(struct{ Env Vars }{Env: vars})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if got, wantJSON := string(b), `{"Env":{"APP_NAME":"app","DB_PASSWORD":"[REDACTED]","LICENSE":"[REDACTED]"}}`; got != wantJSON {
+		t.Errorf("json.Marshal() got = %s, want %s", got, wantJSON)
+	}
+
+	if got := vars.Map(); got["DB_PASSWORD"] != "hunter2" || got["LICENSE"] != "lic-456" {
+		t.Errorf("Map() got = %v, want the actual values", got)
+	}
+}
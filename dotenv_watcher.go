@@ -0,0 +1,288 @@
+package dotenv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ValueChange is the previous and the new value of a key
+type ValueChange struct {
+	Old string
+	New string
+}
+
+// Changes describes how the values parsed by a Watcher have changed
+type Changes struct {
+	// Added holds the keys that were not set before, along with their values
+	Added map[string]string
+	// Changed holds the keys whose values are different
+	Changed map[string]ValueChange
+	// Removed holds the keys that are no longer set, along with their previous values
+	Removed map[string]string
+}
+
+// Empty reports whether nothing has changed
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// Apply makes the same changes to env, such as the process environment returned by OSEnvironment()
+func (c Changes) Apply(env Environment) error {
+	for key, value := range c.Added {
+		if err := env.Set(key, value); err != nil {
+			return err
+		}
+	}
+	for key, change := range c.Changed {
+		if err := env.Set(key, change.New); err != nil {
+			return err
+		}
+	}
+	for key := range c.Removed {
+		if err := env.Unset(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffEnvs(previous, current envVars) Changes {
+	changes := Changes{
+		Added:   make(map[string]string),
+		Changed: make(map[string]ValueChange),
+		Removed: make(map[string]string),
+	}
+
+	for key, value := range current {
+		previousValue, exists := previous[key]
+		switch {
+		case !exists:
+			changes.Added[key] = value
+		case previousValue != value:
+			changes.Changed[key] = ValueChange{Old: previousValue, New: value}
+		}
+	}
+	for key, value := range previous {
+		if _, exists := current[key]; !exists {
+			changes.Removed[key] = value
+		}
+	}
+
+	return changes
+}
+
+// Watcher parses the files again whenever they change and tells its subscribers what changed
+//
+// The environment is read once when the Watcher is created and keeps priority over the files, just
+// as it does with Parse(). Applying changes to the environment therefore never hides a later change
+// to a file. Keys that the environment sets to the same value as the files, such as those set by
+// Load() before the Watcher was created, are left out so that their changes are reported too. The
+// other keys that are set in the environment are never reported as removed; when the files stop
+// setting such a key, it goes back to its value from the environment:
+//
+//	watcher, err := dotenv.NewWatcher(dotenv.EnvironmentFiles("production"))
+//	if err != nil {
+//		return err
+//	}
+//	watcher.Subscribe(func(changes dotenv.Changes) {
+//		_ = changes.Apply(dotenv.OSEnvironment())
+//	})
+//	go watcher.Run(ctx, 5*time.Second)
+type Watcher struct {
+	cfg *envCfg
+	// env holds the environment as it was when the Watcher was created, without the keys set by Load()
+	env envVars
+	// checkMu makes sure that changes are found and sent to subscribers one check at a time
+	checkMu       sync.Mutex
+	mu            sync.Mutex
+	values        envVars
+	files         map[string]watchedFile
+	subscribers   []func(Changes)
+	errorHandlers []func(error)
+}
+
+// watchedFile is what was last seen of a file
+type watchedFile struct {
+	exists  bool
+	modTime int64
+	size    int64
+}
+
+// NewWatcher parses the files using the same options as Parse() and returns a Watcher for them
+func NewWatcher(options ...ParseOption) (*Watcher, error) {
+	cfg := newEnvCfg()
+
+	for _, option := range options {
+		err := option.parseOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	env := cfg.currentEnvs()
+	// keys that already have the value the files give them were most likely set by Load(), and are left
+	// out so that they do not hide later changes to the files
+	fileCfg := *cfg
+	fileCfg.overload = true
+	fileValues, err := parse(&fileCfg)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range fileValues {
+		if envValue, exists := env[key]; exists && envValue == value {
+			delete(env, key)
+		}
+	}
+	cfg.env = MapEnvironment(env)
+
+	w := &Watcher{cfg: cfg, env: env}
+
+	files, err := w.fileStates()
+	if err != nil {
+		return nil, err
+	}
+	values, err := parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w.files, w.values = files, values
+
+	return w, nil
+}
+
+// Values returns the values from the most recent successful parse
+func (w *Watcher) Values() map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return mergeEnvs(w.values)
+}
+
+// Subscribe adds fn to the functions that are called whenever the values change
+func (w *Watcher) Subscribe(fn func(changes Changes)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// OnError adds fn to the functions that are called when Run is unable to parse the files
+//
+// The previous values are kept until the files can be parsed again.
+func (w *Watcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.errorHandlers = append(w.errorHandlers, fn)
+}
+
+// Check parses the files right away and calls the subscribers when any values have changed
+func (w *Watcher) Check() (Changes, error) {
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	files, err := w.fileStates()
+	if err != nil {
+		return Changes{}, err
+	}
+
+	w.mu.Lock()
+	// files that cannot be parsed are not parsed again until they change
+	w.files = files
+	w.mu.Unlock()
+
+	values, err := parse(w.cfg)
+	if err != nil {
+		return Changes{}, err
+	}
+
+	w.mu.Lock()
+	// the environment is included so that keys it sets are never reported as removed
+	changes := diffEnvs(mergeEnvs(w.env, w.values), mergeEnvs(w.env, values))
+	w.values = values
+	subscribers := append([]func(Changes){}, w.subscribers...)
+	w.mu.Unlock()
+
+	if !changes.Empty() {
+		for _, fn := range subscribers {
+			fn(changes)
+		}
+	}
+
+	return changes, nil
+}
+
+// Run checks the files for changes every interval until ctx is done, and then returns ctx.Err()
+//
+// The files are only parsed again once the modification time or the size of one of them changes, or
+// when a file is created or removed.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := w.poll(); err != nil {
+				w.reportError(err)
+			}
+		}
+	}
+}
+
+func (w *Watcher) poll() error {
+	files, err := w.fileStates()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	changed := len(files) != len(w.files)
+	for file, state := range files {
+		if w.files[file] != state {
+			changed = true
+		}
+	}
+	w.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	_, err = w.Check()
+
+	return err
+}
+
+func (w *Watcher) reportError(err error) {
+	w.mu.Lock()
+	handlers := append([]func(error){}, w.errorHandlers...)
+	w.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
+
+func (w *Watcher) fileStates() (map[string]watchedFile, error) {
+	fileNames, err := buildFileList(w.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]watchedFile, len(fileNames))
+	for _, fileName := range fileNames {
+		info, err := w.cfg.stat(fileName)
+		if err != nil {
+			// missing files are watched for in case they are created
+			files[fileName] = watchedFile{}
+			continue
+		}
+		files[fileName] = watchedFile{exists: true, modTime: info.ModTime().UnixNano(), size: info.Size()}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,252 @@
+package dotenv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("FROM_ENV", "env")
+
+	fsys := fstest.MapFS{
+		".env": {Data: []byte("KEEP=1\nCHANGE=old\nREMOVE=1\nFROM_ENV=file\n")},
+	}
+	watcher, err := NewWatcher(FS(fsys))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	var notified []Changes
+	watcher.Subscribe(func(changes Changes) {
+		notified = append(notified, changes)
+	})
+
+	tests := []struct {
+		name       string
+		contents   string
+		want       Changes
+		wantErr    bool
+		wantValues map[string]string
+	}{
+		{
+			name:     "reports added, changed and removed keys",
+			contents: "KEEP=1\nCHANGE=new\nADD=1\nFROM_ENV=changed\n",
+			want: Changes{
+				Added:   map[string]string{"ADD": "1"},
+				Changed: map[string]ValueChange{"CHANGE": {Old: "old", New: "new"}},
+				Removed: map[string]string{"REMOVE": "1"},
+			},
+			wantValues: map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "1", "FROM_ENV": "env"},
+		},
+		{
+			name:     "reports nothing when the values are the same",
+			contents: "# reordered\nCHANGE=new\nADD=1\nKEEP=1\nFROM_ENV=other\n",
+			want: Changes{
+				Added:   map[string]string{},
+				Changed: map[string]ValueChange{},
+				Removed: map[string]string{},
+			},
+			wantValues: map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "1", "FROM_ENV": "env"},
+		},
+		{
+			name:       "keeps the previous values when the file cannot be parsed",
+			contents:   "KEEP=\"1\n",
+			wantErr:    true,
+			wantValues: map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "1", "FROM_ENV": "env"},
+		},
+	}
+	// each step depends on the previous one, so they are run in order
+	for _, tt := range tests {
+		notified = nil
+		fsys[".env"] = &fstest.MapFile{Data: []byte(tt.contents)}
+
+		got, err := watcher.Check()
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: Check() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Check() got = %v, want %v", tt.name, got, tt.want)
+		}
+		var wantNotified []Changes
+		if !tt.wantErr && !tt.want.Empty() {
+			wantNotified = []Changes{tt.want}
+		}
+		if !reflect.DeepEqual(notified, wantNotified) {
+			t.Errorf("%s: subscriber got = %v, want %v", tt.name, notified, wantNotified)
+		}
+		if values := watcher.Values(); !reflect.DeepEqual(values, tt.wantValues) {
+			t.Errorf("%s: Values() got = %v, want %v", tt.name, values, tt.wantValues)
+		}
+	}
+}
+
+func TestWatcherEnvironment(t *testing.T) {
+	tests := map[string]struct {
+		options []ParseOption
+		// applied is the value of HOME_DIR once the files have been applied to the environment
+		applied string
+		want    Changes
+	}{
+		"keeps keys from the environment": {
+			options: []ParseOption{},
+			applied: "/real/home",
+			want: Changes{
+				Added:   map[string]string{},
+				Changed: map[string]ValueChange{},
+				Removed: map[string]string{"OTHER": "1"},
+			},
+		},
+		"restores keys from the environment when overloading": {
+			options: []ParseOption{Overload()},
+			applied: "file",
+			want: Changes{
+				Added:   map[string]string{},
+				Changed: map[string]ValueChange{"HOME_DIR": {Old: "file", New: "/real/home"}},
+				Removed: map[string]string{"OTHER": "1"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			t.Setenv("HOME_DIR", "/real/home")
+
+			fsys := fstest.MapFS{
+				".env": {Data: []byte("HOME_DIR=file\nOTHER=1\n")},
+			}
+			watcher, err := NewWatcher(append(tt.options, FS(fsys))...)
+			if err != nil {
+				t.Fatalf("NewWatcher() error = %v", err)
+			}
+			if err = os.Setenv("HOME_DIR", tt.applied); err != nil {
+				t.Fatal(err)
+			}
+
+			fsys[".env"] = &fstest.MapFile{Data: []byte("# removed\n")}
+			got, err := watcher.Check()
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() got = %v, want %v", got, tt.want)
+			}
+
+			if err = got.Apply(OSEnvironment()); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if value, exists := os.LookupEnv("HOME_DIR"); value != "/real/home" || !exists {
+				t.Errorf("Apply() HOME_DIR = %q, %v, want %q", value, exists, "/real/home")
+			}
+		})
+	}
+}
+
+func TestWatcherAfterLoad(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("HOME_DIR", "/real/home")
+
+	fsys := fstest.MapFS{
+		".env": {Data: []byte("A=1\nHOME_DIR=file\n")},
+	}
+	if err := Load(FS(fsys)); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	watcher, err := NewWatcher(FS(fsys))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	fsys[".env"] = &fstest.MapFile{Data: []byte("A=2\nB=1\nHOME_DIR=changed\n")}
+	got, err := watcher.Check()
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	want := Changes{
+		Added:   map[string]string{"B": "1"},
+		Changed: map[string]ValueChange{"A": {Old: "1", New: "2"}},
+		Removed: map[string]string{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() got = %v, want %v", got, want)
+	}
+}
+
+func TestChangesApply(t *testing.T) {
+	env := map[string]string{"KEEP": "1", "CHANGE": "old", "REMOVE": "1"}
+	changes := Changes{
+		Added:   map[string]string{"ADD": "1"},
+		Changed: map[string]ValueChange{"CHANGE": {Old: "old", New: "new"}},
+		Removed: map[string]string{"REMOVE": "1"},
+	}
+
+	if err := changes.Apply(MapEnvironment(env)); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "1"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("Apply() got = %v, want %v", env, want)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	file := filepath.Join(dir, ".env")
+	if err := os.WriteFile(file, []byte("VALUE=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := NewWatcher(Paths(dir))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	notified := make(chan Changes, 1)
+	watcher.Subscribe(func(changes Changes) {
+		notified <- changes
+	})
+	watcher.Subscribe(func(changes Changes) {
+		if err := changes.Apply(OSEnvironment()); err != nil {
+			t.Errorf("Apply() error = %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx, 10*time.Millisecond)
+	}()
+
+	if err := os.WriteFile(file, []byte("VALUE=2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the size stays the same, so make sure the modification time changes
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changes := <-notified:
+		want := map[string]ValueChange{"VALUE": {Old: "1", New: "2"}}
+		if !reflect.DeepEqual(changes.Changed, want) {
+			t.Errorf("Run() changes got = %v, want %v", changes.Changed, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not notice the change")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if value := os.Getenv("VALUE"); value != "2" {
+		t.Errorf("ENV VALUE got = %q, want %q", value, "2")
+	}
+	os.Clearenv()
+}
@@ -0,0 +1,95 @@
+package dotenv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Marshal returns the contents of an environment variables file holding vars
+//
+// Keys are sorted and each value is quoted only when it needs to be. Parsing the result returns vars.
+func Marshal(vars map[string]string) ([]byte, error) {
+	var b bytes.Buffer
+
+	if err := Write(&b, vars); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Write writes vars to w as the contents of an environment variables file
+//
+// See Marshal for details.
+func Write(w io.Writer, vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		if !isKey(key) {
+			return fmt.Errorf("invalid key: %q", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := io.WriteString(w, key+"="+quoteValue(vars[key])+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quoteValue returns value as it should be written so that it is read back unchanged
+//
+// Values made up of only safe characters are left unquoted. Anything else is double quoted with
+// backslashes, quotes, `$` and line endings escaped.
+func quoteValue(value string) string {
+	if isSafeValue(value) {
+		return value
+	}
+
+	var b strings.Builder
+	b.Grow(len(value) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"', '$':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// isSafeValue reports whether value can be written without quotes
+func isSafeValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; !isNameChar(c) && !strings.ContainsRune("-.,/:@%+=~^*?!", rune(c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isKey(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isKeyChar(s[i]) {
+			return false
+		}
+	}
+
+	return s != ""
+}
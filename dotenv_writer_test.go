@@ -0,0 +1,108 @@
+package dotenv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := map[string]struct {
+		vars    map[string]string
+		want    string
+		wantErr bool
+	}{
+		"sorts keys": {
+			vars:    map[string]string{"B": "2", "A": "1", "C.D": "3"},
+			want:    "A=1\nB=2\nC.D=3\n",
+			wantErr: false,
+		},
+		"leaves safe values unquoted": {
+			vars:    map[string]string{"URL": "postgres://user@localhost:5432/db?sslmode=disable", "EMPTY": ""},
+			want:    "EMPTY=\nURL=postgres://user@localhost:5432/db?sslmode=disable\n",
+			wantErr: false,
+		},
+		"quotes values with spaces": {
+			vars:    map[string]string{"A": " leading", "B": "trailing ", "C": "in between"},
+			want:    "A=\" leading\"\nB=\"trailing \"\nC=\"in between\"\n",
+			wantErr: false,
+		},
+		"escapes special characters": {
+			vars:    map[string]string{"A": "line 1\nline 2\r\n", "B": `say "hi" # not a comment`, "C": `$HOME\path\`, "D": "it's"},
+			want:    "A=\"line 1\\nline 2\\r\\n\"\nB=\"say \\\"hi\\\" # not a comment\"\nC=\"\\$HOME\\\\path\\\\\"\nD=\"it's\"\n",
+			wantErr: false,
+		},
+		"returns an error for invalid keys": {
+			vars:    map[string]string{"NOT VALID": "value"},
+			wantErr: true,
+		},
+		"returns an error for empty keys": {
+			vars:    map[string]string{"": "value"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Marshal(tt.vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() got = %q, want %q", got, tt.want)
+			}
+			parsed, err := parseString(&envCfg{}, "", string(got))
+			if err != nil {
+				t.Fatalf("parseString() error = %v", err)
+			}
+			if !reflect.DeepEqual(map[string]string(parsed), tt.vars) {
+				t.Errorf("parseString() got = %q, want %q", parsed, tt.vars)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, map[string]string{"FOO": "bar baz"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got, want := b.String(), "FOO=\"bar baz\"\n"; got != want {
+		t.Errorf("Write() got = %q, want %q", got, want)
+	}
+}
+
+func FuzzMarshal(f *testing.F) {
+	for _, seed := range []struct{ key, value string }{
+		{"FOO", "bar"},
+		{"FOO", ""},
+		{"export", " spaced out "},
+		{"FOO.BAR", "line 1\nline 2\r\nline 3\r"},
+		{"FOO", `ends with a backslash\`},
+		{"FOO", `\\"quoted\\" $HOME ${HOME:-x} $(whoami)`},
+		{"FOO", "'single' # comment"},
+		{"FOO", "\x00\t\f\v\ufeff"},
+	} {
+		f.Add(seed.key, seed.value)
+	}
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		vars := map[string]string{key: value}
+		contents, err := Marshal(vars)
+		if err != nil {
+			if isKey(key) {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			return
+		}
+		parsed, err := parseString(&envCfg{}, "", string(contents))
+		if err != nil {
+			t.Fatalf("parseString(%q) error = %v", contents, err)
+		}
+		if !reflect.DeepEqual(map[string]string(parsed), vars) {
+			t.Errorf("parseString(%q) got = %q, want %q", contents, parsed, vars)
+		}
+	})
+}
@@ -1,3 +1,3 @@
 module github.com/stackus/dotenv
 
-go 1.17
+go 1.20